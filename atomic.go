@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"path"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// tempKey returns a unique temporary key next to the key.
+func tempKey(key string) (string, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return path.Dir(key) + "/.tmp-" + hex.EncodeToString(id) + "-" + path.Base(key), nil
+}
+
+// CreateFileAtomic make new file through a temporary key, so the readers never
+// see a partially uploaded file. The content is uploaded to the temporary key,
+// copied to the final key by the server and the temporary key is removed.
+func (s helper) CreateFileAtomic(bucket, directory, fileName string, content io.Reader, length int64, mime string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	key := s.uploadKey(directory, fileName)
+	tmp, err := tempKey(key)
+	if err != nil {
+		return errors.Wrap(err, "tempKey failed")
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType: mime,
+	}
+	err = s.putObject(bucket, tmp, content, length, opts)
+	if err != nil {
+		s.removeObject(bucket, tmp)
+		return err
+	}
+
+	err = s.moveObject(bucket, tmp, key)
+	if err != nil {
+		s.removeObject(bucket, tmp)
+		return err
+	}
+
+	return nil
+}
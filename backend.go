@@ -0,0 +1,57 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Backend types of BackendType.
+const (
+	BackendAWS     = "aws"
+	BackendMinIO   = "minio"
+	BackendUnknown = "unknown"
+)
+
+// backendType classifies the server by the headers of its response.
+func backendType(header http.Header) string {
+	if strings.Contains(strings.ToLower(header.Get("Server")), "minio") {
+		return BackendMinIO
+	}
+	for k := range header {
+		if strings.HasPrefix(strings.ToLower(k), "x-minio-") {
+			return BackendMinIO
+		}
+	}
+
+	if header.Get("Server") == "AmazonS3" || header.Get("X-Amz-Bucket-Region") != "" {
+		return BackendAWS
+	}
+
+	return BackendUnknown
+}
+
+// BackendType tells whether the server is AWS S3, MinIO or another S3
+// compatible server, based on the headers of the response to a HEAD request
+// of the configured bucket. The error responses are classified too.
+func (s helper) BackendType() (string, error) {
+	if !s.Enabled {
+		return "", ErrServerDisabled
+	}
+
+	resp, err := s.executeMethod(context.Background(), "HEAD", requestMetadata{
+		bucketName: s.Config.BucketName,
+	})
+	if err, ok := err.(minio.ErrorResponse); ok {
+		return backendType(err.Headers), nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "HeadBucket failed")
+	}
+	resp.Body.Close()
+
+	return backendType(resp.Header), nil
+}
@@ -0,0 +1,64 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBackendType(t *testing.T) {
+	Convey("BackendType", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.BackendType()
+			So(err, ShouldNotBeNil)
+		})
+
+		backend := func(status int, header http.Header) string {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			backend, err := s3.BackendType()
+			So(err, ShouldBeNil)
+			return backend
+		}
+
+		Convey("AWS", func() {
+			So(backend(http.StatusOK, http.Header{"Server": {"AmazonS3"}}), ShouldEqual, BackendAWS)
+			So(backend(http.StatusOK, http.Header{"X-Amz-Bucket-Region": {"eu-west-1"}}), ShouldEqual, BackendAWS)
+			So(backend(http.StatusForbidden, http.Header{"Server": {"AmazonS3"}}), ShouldEqual, BackendAWS)
+		})
+
+		Convey("MinIO", func() {
+			So(backend(http.StatusOK, http.Header{"Server": {"MinIO/RELEASE.2018-06-29T02-11-29Z"}}), ShouldEqual, BackendMinIO)
+			So(backend(http.StatusOK, http.Header{"Server": {"Minio/DEVELOPMENT"}, "X-Amz-Bucket-Region": {"us-east-1"}}), ShouldEqual, BackendMinIO)
+			So(backend(http.StatusNotFound, http.Header{"X-Minio-Deployment-Id": {"abc"}}), ShouldEqual, BackendMinIO)
+		})
+
+		Convey("Unknown", func() {
+			So(backend(http.StatusOK, http.Header{"Server": {"Ceph Object Gateway"}}), ShouldEqual, BackendUnknown)
+			So(backend(http.StatusOK, nil), ShouldEqual, BackendUnknown)
+		})
+
+		Convey("Unreachable", func() {
+			server := httptest.NewServer(http.NotFoundHandler())
+			server.Close()
+
+			s3 := newTestHelper(server)
+
+			_, err := s3.BackendType()
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
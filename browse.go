@@ -0,0 +1,225 @@
+package s3
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Sort orders of BrowseDirectory.
+const (
+	SortByName     = "name"
+	SortBySize     = "size"
+	SortByModified = "modified"
+)
+
+// BrowseDirectory lists the folders and the files directly under the prefix.
+// The folders are sorted by name, the files by sortBy, and the folders come
+// before the files. The offset and the limit select a page of this combined
+// list, a zero limit returns everything after the offset. The total is the
+// number of the folders and the files together. The directory markers of
+// CreateDirectory are not listed.
+func (s helper) BrowseDirectory(bucket, prefix string, sortBy string, ascending bool, offset, limit int) ([]string, []minio.ObjectInfo, int, error) {
+	if !s.Enabled {
+		return nil, nil, 0, ErrServerDisabled
+	}
+
+	err := validation.Validate(sortBy, validation.Required, validation.In(SortByName, SortBySize, SortByModified))
+	if err != nil {
+		return nil, nil, 0, errors.Wrap(err, "invalid sort")
+	}
+	err = validation.Validate(offset, validation.Min(0))
+	if err != nil {
+		return nil, nil, 0, errors.Wrap(err, "invalid offset")
+	}
+	err = validation.Validate(limit, validation.Min(0))
+	if err != nil {
+		return nil, nil, 0, errors.Wrap(err, "invalid limit")
+	}
+
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	folders := []string{}
+	files := []minio.ObjectInfo{}
+	for obj := range s.Client.ListObjectsV2(bucket, prefix, false, doneCh) {
+		if obj.Err != nil {
+			return nil, nil, 0, errors.Wrap(obj.Err, "list object error")
+		}
+
+		if strings.HasSuffix(obj.Key, "/") {
+			folders = append(folders, obj.Key)
+			continue
+		}
+		if path.Base(obj.Key) == directoryMarker {
+			continue
+		}
+		files = append(files, obj)
+	}
+
+	sort.Slice(folders, func(i, j int) bool {
+		if ascending {
+			return folders[i] < folders[j]
+		}
+		return folders[i] > folders[j]
+	})
+
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if !ascending {
+			a, b = b, a
+		}
+
+		switch sortBy {
+		case SortBySize:
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case SortByModified:
+			if !a.LastModified.Equal(b.LastModified) {
+				return a.LastModified.Before(b.LastModified)
+			}
+		}
+		return a.Key < b.Key
+	})
+
+	total := len(folders) + len(files)
+	start, end := offset, total
+	if start > total {
+		start = total
+	}
+	if limit > 0 && start+limit < total {
+		end = start + limit
+	}
+
+	// The page starts in the folders and continues in the files.
+	pageFolders := []string{}
+	if start < len(folders) {
+		pageFolders = folders[start:minInt(end, len(folders))]
+	}
+	pageFiles := []minio.ObjectInfo{}
+	if end > len(folders) {
+		pageFiles = files[maxInt(start-len(folders), 0) : end-len(folders)]
+	}
+
+	return pageFolders, pageFiles, total, nil
+}
+
+// minInt returns the smaller integer.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the larger integer.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ListFilesModifiedSince lists the files under the prefix recursively which
+// were modified after since. The listing can not be filtered by the server,
+// so every object is listed, but only the matching ones are kept.
+func (s helper) ListFilesModifiedSince(bucket, prefix string, since time.Time) ([]minio.ObjectInfo, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	files := []minio.ObjectInfo{}
+	for obj := range s.Client.ListObjectsV2(bucket, prefix, true, doneCh) {
+		if obj.Err != nil {
+			return nil, errors.Wrap(obj.Err, "list object error")
+		}
+		if obj.LastModified.After(since) {
+			files = append(files, obj)
+		}
+	}
+
+	return files, nil
+}
+
+// ListFilesAfter lists the objects under the prefix whose keys come after
+// startAfter, not including it, so a listing can be resumed from the last
+// processed key. Without recursive the folders directly under the prefix are
+// listed as objects with a trailing slash, like ListObjectsV2 does.
+func (s helper) ListFilesAfter(bucket, prefix, startAfter string, recursive bool) ([]minio.ObjectInfo, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	delimiter := "/"
+	if recursive {
+		delimiter = ""
+	}
+
+	core := minio.Core{Client: s.Client}
+	files := []minio.ObjectInfo{}
+	token := ""
+	for {
+		result, err := core.ListObjectsV2(bucket, prefix, token, false, delimiter, 0, startAfter)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListObjectsV2 failed")
+		}
+
+		files = append(files, result.Contents...)
+		for _, folder := range result.CommonPrefixes {
+			files = append(files, minio.ObjectInfo{Key: folder.Prefix})
+		}
+
+		if !result.IsTruncated {
+			return files, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// GlobFiles lists the keys of the files matching the pattern, as path.Match
+// matches them, e.g. images/*/thumb_*.png. The objects under the literal
+// prefix of the pattern are listed by the server, the keys are matched by the
+// client, so a pattern starting with a wildcard lists the whole bucket.
+func (s helper) GlobFiles(bucket, pattern string) ([]string, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid pattern")
+	}
+
+	prefix := pattern
+	if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+		prefix = pattern[:i]
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	keys := []string{}
+	for obj := range s.Client.ListObjectsV2(bucket, prefix, true, doneCh) {
+		if obj.Err != nil {
+			return nil, errors.Wrap(obj.Err, "list object error")
+		}
+		if matched, _ := path.Match(pattern, obj.Key); matched {
+			keys = append(keys, obj.Key)
+		}
+	}
+
+	return keys, nil
+}
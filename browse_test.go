@@ -0,0 +1,230 @@
+package s3
+
+import (
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// objectKeys returns the keys of the objects.
+func objectKeys(objects []minio.ObjectInfo) []string {
+	keys := []string{}
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestBrowse(t *testing.T) {
+	Convey("BrowseDirectory", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, _, err := s3.BrowseDirectory("x43563", "dir", SortByName, true, 0, 10)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid sort", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			_, _, _, err := s3.BrowseDirectory("x43563", "dir", "color", true, 0, 10)
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/b.txt", []byte("1"), nil)
+		server.put("x43563", "dir/a.txt", []byte("123"), nil)
+		server.put("x43563", "dir/c.txt", []byte("12"), nil)
+		server.put("x43563", "dir/sub/d.txt", []byte("1234"), nil)
+		server.put("x43563", "dir/other/e.txt", []byte("1234"), nil)
+		server.put("x43563", "root.txt", []byte("1234"), nil)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Sort by name", func() {
+			folders, files, total, err := s3.BrowseDirectory("x43563", "dir", SortByName, true, 0, 0)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 5)
+			So(folders, ShouldResemble, []string{"dir/other/", "dir/sub/"})
+			So(objectKeys(files), ShouldResemble, []string{"dir/a.txt", "dir/b.txt", "dir/c.txt"})
+		})
+
+		Convey("Sort by size descending", func() {
+			folders, files, total, err := s3.BrowseDirectory("x43563", "dir/", SortBySize, false, 0, 0)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 5)
+			So(folders, ShouldResemble, []string{"dir/sub/", "dir/other/"})
+			So(objectKeys(files), ShouldResemble, []string{"dir/a.txt", "dir/c.txt", "dir/b.txt"})
+		})
+
+		Convey("Pagination", func() {
+			folders, files, total, err := s3.BrowseDirectory("x43563", "dir", SortByName, true, 0, 2)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 5)
+			So(folders, ShouldResemble, []string{"dir/other/", "dir/sub/"})
+			So(files, ShouldBeEmpty)
+
+			folders, files, total, err = s3.BrowseDirectory("x43563", "dir", SortByName, true, 1, 2)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 5)
+			So(folders, ShouldResemble, []string{"dir/sub/"})
+			So(objectKeys(files), ShouldResemble, []string{"dir/a.txt"})
+
+			folders, files, total, err = s3.BrowseDirectory("x43563", "dir", SortBySize, true, 3, 10)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 5)
+			So(folders, ShouldBeEmpty)
+			So(objectKeys(files), ShouldResemble, []string{"dir/c.txt", "dir/a.txt"})
+
+			folders, files, total, err = s3.BrowseDirectory("x43563", "dir", SortByName, true, 10, 10)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 5)
+			So(folders, ShouldBeEmpty)
+			So(files, ShouldBeEmpty)
+		})
+
+		Convey("Directory markers", func() {
+			err := s3.CreateDirectory("x43563", "dir")
+			So(err, ShouldBeNil)
+			err = s3.CreateDirectory("x43563", "dir/empty")
+			So(err, ShouldBeNil)
+
+			folders, files, total, err := s3.BrowseDirectory("x43563", "dir", SortByName, true, 0, 0)
+			So(err, ShouldBeNil)
+			So(total, ShouldEqual, 6)
+			So(folders, ShouldResemble, []string{"dir/empty/", "dir/other/", "dir/sub/"})
+			So(objectKeys(files), ShouldResemble, []string{"dir/a.txt", "dir/b.txt", "dir/c.txt"})
+		})
+	})
+
+	Convey("ListFilesModifiedSince", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.ListFilesModifiedSince("x43563", "dir/", time.Now())
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		since := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+		for key, modified := range map[string]time.Time{
+			"dir/old.txt":       since.Add(-time.Hour),
+			"dir/same.txt":      since,
+			"dir/new.txt":       since.Add(time.Hour),
+			"dir/sub/newer.txt": since.Add(48 * time.Hour),
+			"other/new.txt":     since.Add(time.Hour),
+		} {
+			server.put("x43563", key, []byte("asdf"), nil)
+			obj, _ := server.get("x43563", key)
+			obj.lastModified = modified
+		}
+
+		Convey("Success", func() {
+			files, err := s3.ListFilesModifiedSince("x43563", "dir/", since)
+			So(err, ShouldBeNil)
+			So(objectKeys(files), ShouldResemble, []string{"dir/new.txt", "dir/sub/newer.txt"})
+		})
+	})
+
+	Convey("ListFilesAfter", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.ListFilesAfter("x43563", "dir/", "dir/b.txt", true)
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+		for _, key := range []string{"dir/a.txt", "dir/b.txt", "dir/c.txt", "dir/sub/d.txt", "other/e.txt"} {
+			server.put("x43563", key, []byte("asdf"), nil)
+		}
+
+		Convey("Recursive", func() {
+			files, err := s3.ListFilesAfter("x43563", "dir/", "dir/b.txt", true)
+			So(err, ShouldBeNil)
+			So(objectKeys(files), ShouldResemble, []string{"dir/c.txt", "dir/sub/d.txt"})
+			So(files[0].Size, ShouldEqual, 4)
+		})
+
+		Convey("Not recursive", func() {
+			files, err := s3.ListFilesAfter("x43563", "dir/", "dir/b.txt", false)
+			So(err, ShouldBeNil)
+			So(objectKeys(files), ShouldResemble, []string{"dir/c.txt", "dir/sub/"})
+		})
+
+		Convey("From the start", func() {
+			files, err := s3.ListFilesAfter("x43563", "dir/", "", true)
+			So(err, ShouldBeNil)
+			So(objectKeys(files), ShouldResemble, []string{"dir/a.txt", "dir/b.txt", "dir/c.txt", "dir/sub/d.txt"})
+		})
+	})
+
+	Convey("GlobFiles", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.GlobFiles("x43563", "images/*.png")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+		for _, key := range []string{
+			"images/a/thumb_1.png",
+			"images/a/full_1.png",
+			"images/b/thumb_2.png",
+			"images/b/c/thumb_3.png",
+			"images/thumb_4.png",
+			"docs/readme.txt",
+		} {
+			server.put("x43563", key, []byte("asdf"), nil)
+		}
+
+		Convey("Patterns", func() {
+			for pattern, expected := range map[string][]string{
+				"images/*/thumb_*.png":   {"images/a/thumb_1.png", "images/b/thumb_2.png"},
+				"images/*.png":           {"images/thumb_4.png"},
+				"images/[ab]/*_1.png":    {"images/a/full_1.png", "images/a/thumb_1.png"},
+				"*/readme.???":           {"docs/readme.txt"},
+				"images/b/c/thumb_3.png": {"images/b/c/thumb_3.png"},
+				"images/*.jpg":           {},
+			} {
+				keys, err := s3.GlobFiles("x43563", pattern)
+				So(err, ShouldBeNil)
+				So(keys, ShouldResemble, expected)
+			}
+		})
+
+		Convey("Literal prefix is listed", func() {
+			_, err := s3.GlobFiles("x43563", "images/b/*.png")
+			So(err, ShouldBeNil)
+
+			requests := server.received()
+			last := requests[len(requests)-1]
+			So(last.Query.Get("prefix"), ShouldEqual, "images/b/")
+		})
+
+		Convey("Invalid pattern", func() {
+			_, err := s3.GlobFiles("x43563", "images/[a")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
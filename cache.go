@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"container/list"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// existsCacheCapacity is the maximum number of entries of the exists cache.
+const existsCacheCapacity = 10000
+
+// existsCache caches the results of the existence checks for a limited time.
+// Above the capacity the least recently used entries are evicted. A nil cache
+// is valid and caches nothing.
+type existsCache struct {
+	ttl      time.Duration
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// existsEntry is an entry of the exists cache.
+type existsEntry struct {
+	key     string
+	exists  bool
+	expires time.Time
+}
+
+// newExistsCache creates a new exists cache. It returns nil if the ttl is not
+// positive, which disables the caching.
+func newExistsCache(ttl time.Duration) *existsCache {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return &existsCache{
+		ttl:      ttl,
+		capacity: existsCacheCapacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// get returns the cached result for the key.
+func (c *existsCache) get(key string) (exists bool, ok bool) {
+	if c == nil {
+		return false, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+
+	entry := elem.Value.(*existsEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return false, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.exists, true
+}
+
+// set stores the result for the key.
+func (c *existsCache) set(key string, exists bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &existsEntry{
+		key:     key,
+		exists:  exists,
+		expires: time.Now().Add(c.ttl),
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*existsEntry).key)
+	}
+}
+
+// removePrefix removes the entries whose key starts with the prefix.
+func (c *existsCache) removePrefix(prefix string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// fileCacheKey returns the exists cache key of an object.
+func fileCacheKey(bucket, key string) string {
+	return bucket + "/" + filepath.Clean(key)
+}
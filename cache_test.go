@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExistsCache(t *testing.T) {
+	Convey("existsCache", t, func() {
+		Convey("Disabled", func() {
+			cache := newExistsCache(0)
+			So(cache, ShouldBeNil)
+
+			cache.set("x", true)
+			_, ok := cache.get("x")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Expiration", func() {
+			cache := newExistsCache(10 * time.Millisecond)
+			cache.set("x", true)
+
+			exists, ok := cache.get("x")
+			So(ok, ShouldBeTrue)
+			So(exists, ShouldBeTrue)
+
+			time.Sleep(20 * time.Millisecond)
+			_, ok = cache.get("x")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Eviction", func() {
+			cache := newExistsCache(time.Minute)
+			cache.capacity = 2
+			cache.set("a", true)
+			cache.set("b", true)
+			cache.get("a")
+			cache.set("c", false)
+
+			_, ok := cache.get("b")
+			So(ok, ShouldBeFalse)
+			_, ok = cache.get("a")
+			So(ok, ShouldBeTrue)
+			_, ok = cache.get("c")
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Remove prefix", func() {
+			cache := newExistsCache(time.Minute)
+			cache.set("bucket", true)
+			cache.set("bucket/a", true)
+			cache.set("other/a", true)
+			cache.removePrefix("bucket/")
+
+			_, ok := cache.get("bucket/a")
+			So(ok, ShouldBeFalse)
+			_, ok = cache.get("bucket")
+			So(ok, ShouldBeTrue)
+			_, ok = cache.get("other/a")
+			So(ok, ShouldBeTrue)
+		})
+	})
+
+	Convey("FileExists with cache", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), nil)
+
+		config := testConfig(server.Server)
+		config.ExistsCacheTTL = time.Minute
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Second call is cached", func() {
+			exists, err := s3.FileExists("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+			requests := len(server.received())
+
+			exists, err = s3.FileExists("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+			So(server.received(), ShouldHaveLength, requests)
+		})
+
+		Convey("Delete invalidates", func() {
+			exists, err := s3.FileExists("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+
+			err = s3.RemoveFile("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+
+			exists, err = s3.FileExists("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+		})
+
+		Convey("Create updates", func() {
+			exists, err := s3.FileExists("x43563", "dir", "new.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+
+			err = s3.CreateFile("x43563", "dir", "new.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			requests := len(server.received())
+
+			exists, err = s3.FileExists("x43563", "dir", "new.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+			So(server.received(), ShouldHaveLength, requests)
+		})
+	})
+}
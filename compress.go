@@ -0,0 +1,105 @@
+package s3
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// gzipReadCloser closes both the gzip reader and the underlying object.
+type gzipReadCloser struct {
+	*gzip.Reader
+	obj io.Closer
+}
+
+// Close closes the readers.
+func (r gzipReadCloser) Close() error {
+	err := r.Reader.Close()
+	if closeErr := r.obj.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// openObject opens the object and returns its info. The found flag is false if
+// the object does not exist.
+func (s helper) openObject(bucket, key string, opts minio.GetObjectOptions) (*minio.Object, minio.ObjectInfo, bool, error) {
+	obj, err := s.Client.GetObject(bucket, key, opts)
+	if err != nil {
+		return nil, minio.ObjectInfo{}, false, errors.Wrap(err, "GetObject failed")
+	}
+
+	info, err := obj.Stat()
+	if err, ok := err.(minio.ErrorResponse); ok && (err.Code == "NoSuchKey") {
+		obj.Close()
+		return nil, minio.ObjectInfo{}, false, nil
+	}
+	if err != nil {
+		obj.Close()
+		return nil, minio.ObjectInfo{}, false, errors.Wrap(err, "Stat failed")
+	}
+
+	return obj, info, true, nil
+}
+
+// GetFileDecoded returns the content of the file, decompressed if it was
+// stored with gzip content encoding. The found flag is false if the file does
+// not exist. The caller must close the reader.
+func (s helper) GetFileDecoded(bucket, directory, filename string) (io.ReadCloser, bool, error) {
+	if !s.Enabled {
+		return nil, false, ErrServerDisabled
+	}
+
+	obj, info, found, err := s.openObject(bucket, filepath.Join(directory, filename), minio.GetObjectOptions{})
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	if !strings.EqualFold(info.Metadata.Get("Content-Encoding"), "gzip") {
+		return obj, true, nil
+	}
+
+	reader, err := gzip.NewReader(obj)
+	if err != nil {
+		obj.Close()
+		return nil, false, errors.Wrap(err, "gzip.NewReader failed")
+	}
+
+	return gzipReadCloser{Reader: reader, obj: obj}, true, nil
+}
+
+// CreateFileCompressed make new file with the gzip compressed content and
+// gzip content encoding. The content is compressed while it is uploaded, so
+// the compressed length is unknown and the file is uploaded like by
+// CreateFileStream, buffering up to a part of the compressed content.
+func (s helper) CreateFileCompressed(bucket, directory, fileName string, content io.Reader, mime string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(writer)
+		_, err := io.Copy(gz, content)
+		if err == nil {
+			err = gz.Close()
+		}
+		if err != nil {
+			err = errors.Wrap(err, "gzip failed")
+		}
+		writer.CloseWithError(err)
+	}()
+	// Closing the reader stops the compression if the upload failed.
+	defer reader.Close()
+
+	opts := minio.PutObjectOptions{
+		ContentType:     mime,
+		ContentEncoding: "gzip",
+	}
+
+	return s.putObject(bucket, s.uploadKey(directory, fileName), reader, -1, opts)
+}
@@ -0,0 +1,125 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// gzipped returns the gzip compressed data.
+func gzipped(data []byte) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestCompress(t *testing.T) {
+	Convey("GetFileDecoded", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetFileDecoded("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Gzipped", func() {
+			content := gzipped([]byte("hello world"))
+			err := s3.CreateFile("x43563", "dir", "file.txt", bytes.NewReader(content), int64(len(content)), "text/plain", WithHeader("Content-Encoding", "gzip"))
+			So(err, ShouldBeNil)
+
+			reader, found, err := s3.GetFileDecoded("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			defer reader.Close()
+
+			data, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "hello world")
+		})
+
+		Convey("Plain", func() {
+			err := s3.CreateFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("hello world")), 11, "text/plain")
+			So(err, ShouldBeNil)
+
+			reader, found, err := s3.GetFileDecoded("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			defer reader.Close()
+
+			data, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "hello world")
+		})
+
+		Convey("Missing", func() {
+			reader, found, err := s3.GetFileDecoded("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+			So(reader, ShouldBeNil)
+		})
+	})
+
+	Convey("CreateFileCompressed", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CreateFileCompressed("x43563", "dir", "file.txt", bytes.NewReader([]byte("hello world")), "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := newFakeS3()
+			defer server.Close()
+			s3 := newTestHelper(server.Server)
+
+			err := s3.CreateFileCompressed("x43563", "dir", "file.txt", bytes.NewReader([]byte("hello world")), "text/plain")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.header.Get("Content-Encoding"), ShouldEqual, "gzip")
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+
+			reader, err := gzip.NewReader(bytes.NewReader(obj.data))
+			So(err, ShouldBeNil)
+			data, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "hello world")
+
+			decoded, found, err := s3.GetFileDecoded("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			defer decoded.Close()
+			data, err = ioutil.ReadAll(decoded)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "hello world")
+		})
+
+		Convey("Read error", func() {
+			server := newFakeS3()
+			defer server.Close()
+			s3 := newTestHelper(server.Server)
+
+			err := s3.CreateFileCompressed("x43563", "dir", "file.txt", iotest.TimeoutReader(bytes.NewReader([]byte("hello world"))), "text/plain")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "gzip failed")
+
+			_, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,412 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// replacedHeaders are the headers of the object which are sent again when the
+// metadata is replaced by a copy.
+var replacedHeaders = []string{
+	"Content-Encoding",
+	"Content-Disposition",
+	"Content-Language",
+	"Cache-Control",
+	"Expires",
+	"X-Amz-Storage-Class",
+	"X-Amz-Website-Redirect-Location",
+	"X-Amz-Server-Side-Encryption",
+	"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
+}
+
+// Limits of the server side copies.
+const (
+	// maxCopyObjectSize is the size of the largest object which can be copied
+	// in a single request, the larger ones are copied in parts.
+	maxCopyObjectSize = 5 << 30
+	// copyPartSize is the part size of the copies in parts.
+	copyPartSize = 512 << 20
+)
+
+// SourceRef references a file in a bucket.
+type SourceRef struct {
+	Bucket    string
+	Directory string
+	FileName  string
+}
+
+// key returns the object key of the file.
+func (r SourceRef) key() string {
+	return filepath.Join(r.Directory, r.FileName)
+}
+
+// CopyFileWithTags copies the file. If replaceTags is true the copy is tagged
+// with the given tags, otherwise it keeps the tags of the source and the given
+// tags are ignored.
+func (s helper) CopyFileWithTags(src, dst SourceRef, tags map[string]string, replaceTags bool) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	headers := map[string]string{
+		"X-Amz-Tagging-Directive": "COPY",
+	}
+	if replaceTags {
+		values := url.Values{}
+		for k, v := range tags {
+			values.Set(k, v)
+		}
+		headers["X-Amz-Tagging-Directive"] = "REPLACE"
+		headers["X-Amz-Tagging"] = values.Encode()
+	}
+
+	info, found, err := s.statObject(src.Bucket, src.key())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("file not found")
+	}
+
+	err = s.copyObject(src.Bucket, src.key(), dst.Bucket, dst.key(), info.Size, headers)
+	if err != nil {
+		return err
+	}
+
+	s.cache.set(fileCacheKey(dst.Bucket, dst.key()), true)
+	return nil
+}
+
+// RenameFile renames the file within its directory. The file is copied to the
+// new name with its content type and metadata, then the old one is removed.
+func (s helper) RenameFile(bucket, directory, oldName, newName string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	if newName == "" || strings.Contains(newName, "/") {
+		return errors.Errorf("invalid file name: %s", newName)
+	}
+	if oldName == newName {
+		return nil
+	}
+
+	return s.moveObject(bucket, filepath.Join(directory, oldName), filepath.Join(directory, newName))
+}
+
+// ChangeStorageClass moves the file to the storage class by copying it onto
+// itself. The content type and the metadata of the file are kept.
+func (s helper) ChangeStorageClass(bucket, directory, filename, storageClass string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validateStorageClass(storageClass)
+	if err != nil {
+		return errors.Wrap(err, "invalid storage class")
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("file not found")
+	}
+
+	return s.copyOntoItself(bucket, filepath.Join(directory, filename), info, map[string]string{
+		"X-Amz-Storage-Class": storageClass,
+	})
+}
+
+// SetRedirect sets the website redirect location of the file, which S3 static
+// website hosting answers with a redirect to the target. The target must be an
+// absolute path or an http(s) URL. An existing file is copied onto itself
+// keeping its content and metadata, a missing one is created empty.
+func (s helper) SetRedirect(bucket, directory, filename, target string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	if !strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		return errors.Errorf("invalid redirect target: %s", target)
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil {
+		return err
+	}
+
+	key := filepath.Join(directory, filename)
+	if !found {
+		err = s.putObject(bucket, key, bytes.NewReader(nil), 0, minio.PutObjectOptions{
+			WebsiteRedirectLocation: target,
+		})
+		if err != nil {
+			return err
+		}
+		s.cache.set(fileCacheKey(bucket, key), true)
+		return nil
+	}
+
+	return s.copyOntoItself(bucket, key, info, map[string]string{
+		"X-Amz-Website-Redirect-Location": target,
+	})
+}
+
+// TouchFile updates the last modified time of the file by copying it onto
+// itself. The content, the content type and the metadata of the file are kept.
+func (s helper) TouchFile(bucket, directory, filename string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("file not found")
+	}
+
+	return s.copyOntoItself(bucket, filepath.Join(directory, filename), info, nil)
+}
+
+// copyOntoItself copies the object onto itself with the changed headers. The
+// content type, the metadata, the storage class, the redirect location, the
+// encryption and the tags of the object are kept unless changed.
+func (s helper) copyOntoItself(bucket, key string, info minio.ObjectInfo, changed map[string]string) error {
+	// A copy onto itself must replace the metadata, which would be lost
+	// unless it is sent again.
+	headers := replaceMetadataHeaders(info)
+	headers["X-Amz-Tagging-Directive"] = "COPY"
+	for k, v := range changed {
+		headers[k] = v
+	}
+
+	return s.copyObject(bucket, key, bucket, key, info.Size, headers)
+}
+
+// copyObject copies the object of the given size with the headers of a copy
+// request. The objects larger than 5GiB are copied in parts.
+func (s helper) copyObject(srcBucket, srcKey, dstBucket, dstKey string, size int64, headers map[string]string) error {
+	if size > maxCopyObjectSize {
+		return s.copyObjectMultipart(srcBucket, srcKey, dstBucket, dstKey, copyPartSize, headers)
+	}
+
+	core := minio.Core{Client: s.Client}
+	_, err := core.CopyObject(srcBucket, srcKey, dstBucket, dstKey, headers)
+	if err != nil {
+		return errors.Wrap(err, "CopyObject failed")
+	}
+
+	return nil
+}
+
+// copyObjectMultipart copies the object in parts of the given size, or larger
+// if the object would not fit into the maximum number of parts. The directives
+// of the headers are not supported by the multipart uploads, so the metadata
+// and the tags of the source are sent with the upload unless they are
+// replaced. The upload is aborted if any of the parts fails, the error is
+// returned as AbortedUploadError.
+func (s helper) copyObjectMultipart(srcBucket, srcKey, dstBucket, dstKey string, partSize int64, headers map[string]string) error {
+	info, found, err := s.statObject(srcBucket, srcKey)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("file not found")
+	}
+
+	header := http.Header{}
+	if headers["X-Amz-Metadata-Directive"] != "REPLACE" {
+		for k, v := range replaceMetadataHeaders(info) {
+			header.Set(k, v)
+		}
+	}
+	for k, v := range headers {
+		header.Set(k, v)
+	}
+	if header.Get("X-Amz-Tagging-Directive") != "REPLACE" {
+		tags, err := s.getObjectTags(srcBucket, srcKey)
+		if err != nil {
+			return err
+		}
+		header.Set("X-Amz-Tagging", tags.Encode())
+	}
+	header.Del("X-Amz-Metadata-Directive")
+	header.Del("X-Amz-Tagging-Directive")
+	if header.Get("X-Amz-Tagging") == "" {
+		header.Del("X-Amz-Tagging")
+	}
+
+	uploadID, err := s.newMultipartUpload(context.Background(), dstBucket, dstKey, header)
+	if err != nil {
+		return errors.Wrap(err, "NewMultipartUpload failed")
+	}
+
+	core := minio.Core{Client: s.Client}
+	abort := func(err error) error {
+		return &AbortedUploadError{
+			Err:      err,
+			UploadID: uploadID,
+			AbortErr: core.AbortMultipartUpload(dstBucket, dstKey, uploadID),
+		}
+	}
+
+	partSize = scalePartSize(partSize, info.Size)
+	parts := []minio.CompletePart{}
+	for offset := int64(0); offset < info.Size; offset += partSize {
+		length := partSize
+		if offset+length > info.Size {
+			length = info.Size - offset
+		}
+
+		part, err := core.CopyObjectPart(srcBucket, srcKey, dstBucket, dstKey, uploadID, len(parts)+1, offset, length, nil)
+		if err != nil {
+			return abort(errors.Wrap(err, "CopyObjectPart failed"))
+		}
+		parts = append(parts, part)
+	}
+
+	_, err = core.CompleteMultipartUpload(dstBucket, dstKey, uploadID, parts)
+	if err != nil {
+		return abort(errors.Wrap(err, "CompleteMultipartUpload failed"))
+	}
+
+	return nil
+}
+
+// replaceMetadataHeaders returns the headers of a copy which replace the
+// metadata of the copy with the content type, the metadata, the storage class,
+// the redirect location and the encryption of the object.
+func replaceMetadataHeaders(info minio.ObjectInfo) map[string]string {
+	headers := map[string]string{
+		"X-Amz-Metadata-Directive": "REPLACE",
+	}
+	if info.ContentType != "" {
+		headers["Content-Type"] = info.ContentType
+	}
+	for _, header := range replacedHeaders {
+		if value := info.Metadata.Get(header); value != "" {
+			headers[header] = value
+		}
+	}
+	for k, v := range info.Metadata {
+		if strings.HasPrefix(strings.ToLower(k), "x-amz-meta-") && len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	return headers
+}
+
+// objectTagging is the tag set of an object.
+type objectTagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Tags    []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"TagSet>Tag"`
+}
+
+// getObjectTags returns the tags of the object.
+func (s helper) getObjectTags(bucket, key string) (url.Values, error) {
+	resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+		bucketName:  bucket,
+		objectName:  key,
+		queryValues: url.Values{"tagging": {""}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetObjectTagging failed")
+	}
+	defer resp.Body.Close()
+
+	tagging := objectTagging{}
+	err = xml.NewDecoder(resp.Body).Decode(&tagging)
+	if err != nil {
+		return nil, errors.Wrap(err, "xml.Decode failed")
+	}
+
+	tags := url.Values{}
+	for _, tag := range tagging.Tags {
+		tags.Set(tag.Key, tag.Value)
+	}
+	return tags, nil
+}
+
+// getObjectACL returns the access control policy document of the object.
+func (s helper) getObjectACL(bucket, key string) ([]byte, error) {
+	resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+		bucketName:  bucket,
+		objectName:  key,
+		queryValues: url.Values{"acl": {""}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "GetObjectAcl failed")
+	}
+	defer resp.Body.Close()
+
+	acl, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read failed")
+	}
+	return acl, nil
+}
+
+// CloneFile copies the file with its content type, metadata, tags and ACL. A
+// copy keeps the metadata and the tags only if the server supports it and
+// never keeps the ACL, so they are read from the source and set on the copy.
+func (s helper) CloneFile(src, dst SourceRef) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	info, found, err := s.statFile(src.Bucket, src.Directory, src.FileName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("file not found")
+	}
+
+	tags, err := s.getObjectTags(src.Bucket, src.key())
+	if err != nil {
+		return err
+	}
+	acl, err := s.getObjectACL(src.Bucket, src.key())
+	if err != nil {
+		return err
+	}
+
+	headers := replaceMetadataHeaders(info)
+	headers["X-Amz-Tagging-Directive"] = "REPLACE"
+	headers["X-Amz-Tagging"] = tags.Encode()
+
+	err = s.copyObject(src.Bucket, src.key(), dst.Bucket, dst.key(), info.Size, headers)
+	if err != nil {
+		return err
+	}
+	s.cache.set(fileCacheKey(dst.Bucket, dst.key()), true)
+
+	resp, err := s.executeMethod(context.Background(), "PUT", requestMetadata{
+		bucketName:  dst.Bucket,
+		objectName:  dst.key(),
+		queryValues: url.Values{"acl": {""}},
+		content:     acl,
+	})
+	if err != nil {
+		return errors.Wrap(err, "PutObjectAcl failed")
+	}
+	resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,396 @@
+package s3
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCopy(t *testing.T) {
+	Convey("CopyFileWithTags", t, func() {
+		src := SourceRef{Bucket: "x43563", Directory: "dir", FileName: "file.txt"}
+		dst := SourceRef{Bucket: "x43564", Directory: "other", FileName: "copy.txt"}
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CopyFileWithTags(src, dst, nil, false)
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), http.Header{"X-Amz-Tagging": {"team=a"}})
+		s3 := newTestHelper(server.Server)
+
+		lastHeader := func() http.Header {
+			requests := server.received()
+			return requests[len(requests)-1].Header
+		}
+
+		Convey("Keep tags", func() {
+			err := s3.CopyFileWithTags(src, dst, map[string]string{"team": "b"}, false)
+			So(err, ShouldBeNil)
+			So(lastHeader().Get("X-Amz-Tagging-Directive"), ShouldEqual, "COPY")
+
+			obj, ok := server.get("x43564", "other/copy.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.tags, ShouldResemble, url.Values{"team": {"a"}})
+		})
+
+		Convey("Replace tags", func() {
+			err := s3.CopyFileWithTags(src, dst, map[string]string{"team": "b", "env": "prod"}, true)
+			So(err, ShouldBeNil)
+			So(lastHeader().Get("X-Amz-Tagging-Directive"), ShouldEqual, "REPLACE")
+			So(lastHeader().Get("X-Amz-Tagging"), ShouldEqual, "env=prod&team=b")
+
+			obj, ok := server.get("x43564", "other/copy.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.tags, ShouldResemble, url.Values{"team": {"b"}, "env": {"prod"}})
+		})
+
+		Convey("Missing source", func() {
+			err := s3.CopyFileWithTags(SourceRef{Bucket: "x43563", Directory: "dir", FileName: "missing.txt"}, dst, nil, false)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("RenameFile", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.RenameFile("x43563", "dir", "file.txt", "renamed.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), http.Header{
+			"Content-Type":     {"text/plain"},
+			"X-Amz-Meta-Owner": {"alice"},
+		})
+		s3 := newTestHelper(server.Server)
+
+		Convey("Success", func() {
+			err := s3.RenameFile("x43563", "dir", "file.txt", "renamed.txt")
+			So(err, ShouldBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"dir/renamed.txt"})
+
+			obj, ok := server.get("x43563", "dir/renamed.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+			So(obj.header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+		})
+
+		Convey("Invalid name", func() {
+			err := s3.RenameFile("x43563", "dir", "file.txt", "other/renamed.txt")
+			So(err, ShouldNotBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"dir/file.txt"})
+		})
+
+		Convey("Missing file", func() {
+			err := s3.RenameFile("x43563", "dir", "missing.txt", "renamed.txt")
+			So(err, ShouldNotBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"dir/file.txt"})
+		})
+	})
+
+	Convey("copyObject", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		content := []byte("0123456789abcdefghij")
+		server.put("x43563", "dir/file.txt", content, http.Header{
+			"Content-Type":     {"text/plain"},
+			"X-Amz-Meta-Owner": {"alice"},
+			"X-Amz-Tagging":    {"team=a"},
+		})
+		s3 := newTestHelper(server.Server).(*helper)
+
+		copies := func() []fakeRequest {
+			requests := []fakeRequest{}
+			for _, r := range server.received() {
+				if r.Method == "PUT" && r.Header.Get("X-Amz-Copy-Source") != "" {
+					requests = append(requests, r)
+				}
+			}
+			return requests
+		}
+
+		Convey("Small object", func() {
+			err := s3.copyObject("x43563", "dir/file.txt", "x43563", "dir/copy.txt", int64(len(content)), nil)
+			So(err, ShouldBeNil)
+			So(copies(), ShouldHaveLength, 1)
+			So(copies()[0].Query.Get("uploadId"), ShouldBeEmpty)
+		})
+
+		Convey("Large object", func() {
+			err := s3.copyObject("x43563", "dir/file.txt", "x43563", "dir/copy.txt", maxCopyObjectSize+1, nil)
+			So(err, ShouldBeNil)
+			So(copies(), ShouldHaveLength, 1)
+			So(copies()[0].Query.Get("uploadId"), ShouldNotBeEmpty)
+
+			obj, ok := server.get("x43563", "dir/copy.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+			So(obj.header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+			So(obj.tags, ShouldResemble, url.Values{"team": {"a"}})
+		})
+
+		Convey("In parts", func() {
+			err := s3.copyObjectMultipart("x43563", "dir/file.txt", "x43563", "dir/copy.txt", 8, map[string]string{
+				"X-Amz-Metadata-Directive": "REPLACE",
+				"Content-Type":             "text/csv",
+				"X-Amz-Tagging-Directive":  "REPLACE",
+				"X-Amz-Tagging":            "team=b",
+			})
+			So(err, ShouldBeNil)
+
+			ranges := []string{}
+			for _, r := range copies() {
+				ranges = append(ranges, r.Header.Get("X-Amz-Copy-Source-Range"))
+			}
+			So(ranges, ShouldResemble, []string{"bytes=0-7", "bytes=8-15", "bytes=16-19"})
+
+			obj, ok := server.get("x43563", "dir/copy.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+			So(obj.etag, ShouldEndWith, "-3")
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/csv")
+			So(obj.header.Get("X-Amz-Meta-Owner"), ShouldBeEmpty)
+			So(obj.tags, ShouldResemble, url.Values{"team": {"b"}})
+			So(server.uploadIDs(), ShouldBeEmpty)
+		})
+
+		Convey("Missing source", func() {
+			err := s3.copyObjectMultipart("x43563", "dir/missing.txt", "x43563", "dir/copy.txt", 8, nil)
+			So(err, ShouldNotBeNil)
+			So(server.uploadIDs(), ShouldBeEmpty)
+		})
+	})
+
+	Convey("ChangeStorageClass", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.ChangeStorageClass("x43563", "dir", "file.txt", StorageClassGlacier)
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), http.Header{
+			"Content-Type":     {"text/plain"},
+			"Cache-Control":    {"no-cache"},
+			"X-Amz-Meta-Owner": {"alice"},
+		})
+		s3 := newTestHelper(server.Server)
+
+		Convey("Success", func() {
+			err := s3.ChangeStorageClass("x43563", "dir", "file.txt", StorageClassStandardIA)
+			So(err, ShouldBeNil)
+
+			requests := server.received()
+			last := requests[len(requests)-1]
+			So(last.Header.Get("X-Amz-Copy-Source"), ShouldEndWith, "x43563/dir/file.txt")
+			So(last.Header.Get("X-Amz-Metadata-Directive"), ShouldEqual, "REPLACE")
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.header.Get("X-Amz-Storage-Class"), ShouldEqual, StorageClassStandardIA)
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+			So(obj.header.Get("Cache-Control"), ShouldEqual, "no-cache")
+			So(obj.header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+		})
+
+		Convey("Tags and encryption", func() {
+			server.put("x43563", "dir/tagged.txt", []byte("asdf"), http.Header{
+				"X-Amz-Tagging":                {"team=a"},
+				"X-Amz-Server-Side-Encryption": {"AES256"},
+			})
+
+			err := s3.ChangeStorageClass("x43563", "dir", "tagged.txt", StorageClassStandardIA)
+			So(err, ShouldBeNil)
+
+			requests := server.received()
+			last := requests[len(requests)-1]
+			So(last.Header.Get("X-Amz-Tagging-Directive"), ShouldEqual, "COPY")
+			So(last.Header.Get("X-Amz-Server-Side-Encryption"), ShouldEqual, "AES256")
+
+			obj, ok := server.get("x43563", "dir/tagged.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.tags, ShouldResemble, url.Values{"team": {"a"}})
+		})
+
+		Convey("Invalid storage class", func() {
+			err := s3.ChangeStorageClass("x43563", "dir", "file.txt", "COLD")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Missing file", func() {
+			err := s3.ChangeStorageClass("x43563", "dir", "missing.txt", StorageClassGlacier)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("SetRedirect", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.SetRedirect("x43563", "dir", "file.html", "/new.html")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.html", []byte("<html></html>"), http.Header{
+			"Content-Type":        {"text/html"},
+			"X-Amz-Storage-Class": {StorageClassStandardIA},
+			"X-Amz-Meta-Owner":    {"alice"},
+		})
+		s3 := newTestHelper(server.Server)
+
+		Convey("Existing file", func() {
+			err := s3.SetRedirect("x43563", "dir", "file.html", "https://example.com/new.html")
+			So(err, ShouldBeNil)
+
+			requests := server.received()
+			last := requests[len(requests)-1]
+			So(last.Header.Get("X-Amz-Copy-Source"), ShouldEndWith, "x43563/dir/file.html")
+			So(last.Header.Get("X-Amz-Website-Redirect-Location"), ShouldEqual, "https://example.com/new.html")
+
+			obj, ok := server.get("x43563", "dir/file.html")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("<html></html>"))
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/html")
+			So(obj.header.Get("X-Amz-Storage-Class"), ShouldEqual, StorageClassStandardIA)
+			So(obj.header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+		})
+
+		Convey("Missing file", func() {
+			err := s3.SetRedirect("x43563", "dir", "old.html", "/dir/file.html")
+			So(err, ShouldBeNil)
+
+			requests := server.received()
+			last := requests[len(requests)-1]
+			So(last.Method, ShouldEqual, "PUT")
+			So(last.Header.Get("X-Amz-Website-Redirect-Location"), ShouldEqual, "/dir/file.html")
+
+			obj, ok := server.get("x43563", "dir/old.html")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldBeEmpty)
+		})
+
+		Convey("Invalid target", func() {
+			err := s3.SetRedirect("x43563", "dir", "file.html", "new.html")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("TouchFile", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.TouchFile("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("content"), http.Header{
+			"Content-Type":     {"text/plain"},
+			"X-Amz-Meta-Owner": {"alice"},
+		})
+		obj, _ := server.get("x43563", "dir/file.txt")
+		touched := obj.lastModified
+		obj.lastModified = touched.Add(-time.Hour)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Existing file", func() {
+			err := s3.TouchFile("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.lastModified, ShouldHappenOnOrAfter, touched)
+			So(obj.data, ShouldResemble, []byte("content"))
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+			So(obj.header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+		})
+
+		Convey("Missing file", func() {
+			err := s3.TouchFile("x43563", "dir", "missing.txt")
+			So(err, ShouldNotBeNil)
+			So(server.count("PUT"), ShouldEqual, 0)
+		})
+	})
+
+	Convey("CloneFile", t, func() {
+		src := SourceRef{Bucket: "x43563", Directory: "dir", FileName: "file.txt"}
+		dst := SourceRef{Bucket: "y43563", Directory: "copy", FileName: "file.txt"}
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CloneFile(src, dst)
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), http.Header{
+			"Content-Type":     {"text/plain"},
+			"Cache-Control":    {"no-cache"},
+			"X-Amz-Meta-Owner": {"alice"},
+			"X-Amz-Tagging":    {"team=a&env=prod"},
+		})
+		obj, _ := server.get("x43563", "dir/file.txt")
+		obj.acl = "<AccessControlPolicy><AccessControlList><Grant><Permission>READ</Permission></Grant></AccessControlList></AccessControlPolicy>"
+		s3 := newTestHelper(server.Server)
+
+		Convey("Success", func() {
+			err := s3.CloneFile(src, dst)
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("y43563", "copy/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+			So(obj.header.Get("Cache-Control"), ShouldEqual, "no-cache")
+			So(obj.header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+			So(obj.tags, ShouldResemble, url.Values{"team": {"a"}, "env": {"prod"}})
+			So(obj.acl, ShouldContainSubstring, "<Permission>READ</Permission>")
+
+			for _, r := range server.received() {
+				if r.Header.Get("X-Amz-Copy-Source") != "" {
+					So(r.Header.Get("X-Amz-Metadata-Directive"), ShouldEqual, "REPLACE")
+					So(r.Header.Get("X-Amz-Tagging-Directive"), ShouldEqual, "REPLACE")
+				}
+			}
+		})
+
+		Convey("Missing file", func() {
+			err := s3.CloneFile(SourceRef{Bucket: "x43563", Directory: "dir", FileName: "missing.txt"}, dst)
+			So(err, ShouldNotBeNil)
+			So(server.keys("y43563"), ShouldBeEmpty)
+		})
+	})
+}
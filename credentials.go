@@ -0,0 +1,45 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// validateStaticKey checks that the static keys are set, unless the requests
+// are anonymous, which can not be combined with the keys.
+func (c Config) validateStaticKey(value interface{}) error {
+	if c.Anonymous {
+		if value.(string) != "" {
+			return errors.New("must be blank with anonymous credentials")
+		}
+		return nil
+	}
+	return validation.Required.Validate(value)
+}
+
+// VerifyCredentials checks the credentials by listing the buckets. It returns
+// ErrInvalidCredentials if the server rejects them and ErrUnreachable if the
+// server can not be reached, use errors.Cause to compare.
+func (s helper) VerifyCredentials(ctx context.Context) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	resp, err := s.executeMethod(ctx, "GET", requestMetadata{})
+	if err, ok := err.(minio.ErrorResponse); ok {
+		if err.StatusCode == http.StatusForbidden {
+			return errors.Wrap(ErrInvalidCredentials, err.Code)
+		}
+		return errors.Wrap(err, "ListBuckets failed")
+	}
+	if err != nil {
+		return errors.Wrap(ErrUnreachable, err.Error())
+	}
+	resp.Body.Close()
+
+	return nil
+}
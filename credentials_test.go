@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCredentials(t *testing.T) {
+	Convey("Credential modes", t, func() {
+		config := Config{
+			Endpoint:   "localhost",
+			Region:     "x",
+			BucketName: "x",
+		}
+
+		Convey("Static keys", func() {
+			config.AccessKeyID = "x"
+			config.SecretAccessKey = "x"
+			So(config.Validate(), ShouldBeNil)
+		})
+
+		Convey("Missing static keys", func() {
+			config.AccessKeyID = "x"
+			So(config.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("Anonymous", func() {
+			server := newFakeS3()
+			defer server.Close()
+			config.Endpoint = testConfig(server.Server).Endpoint
+			config.Anonymous = true
+			So(config.Validate(), ShouldBeNil)
+
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			_, err = s3.FileExists("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+
+			requests := server.received()
+			So(requests, ShouldNotBeEmpty)
+			So(requests[len(requests)-1].Header.Get("Authorization"), ShouldBeEmpty)
+		})
+
+		Convey("Anonymous with access key", func() {
+			config.Anonymous = true
+			config.AccessKeyID = "x"
+			err := config.Validate()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "access_key_id: must be blank with anonymous credentials")
+		})
+
+		Convey("Anonymous with secret key", func() {
+			config.Anonymous = true
+			config.SecretAccessKey = "x"
+			err := config.Validate()
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "secret_access_key: must be blank with anonymous credentials")
+		})
+	})
+
+	Convey("VerifyCredentials", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.VerifyCredentials(context.Background())
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Valid", func() {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.Write([]byte("<ListAllMyBucketsResult><Buckets></Buckets></ListAllMyBucketsResult>"))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.VerifyCredentials(context.Background())
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, "/")
+		})
+
+		Convey("Invalid", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte("<Error><Code>InvalidAccessKeyId</Code></Error>"))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.VerifyCredentials(context.Background())
+			So(errors.Cause(err), ShouldEqual, ErrInvalidCredentials)
+		})
+
+		Convey("Unreachable", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			s3 := newTestHelper(server)
+			server.Close()
+
+			err := s3.VerifyCredentials(context.Background())
+			So(errors.Cause(err), ShouldEqual, ErrUnreachable)
+		})
+
+		Convey("Other error", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.VerifyCredentials(context.Background())
+			So(err, ShouldNotBeNil)
+			So(errors.Cause(err), ShouldNotEqual, ErrInvalidCredentials)
+			So(errors.Cause(err), ShouldNotEqual, ErrUnreachable)
+		})
+	})
+}
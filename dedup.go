@@ -0,0 +1,61 @@
+package s3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// CreateFileDedup stores the content under its SHA256 hash in the directory,
+// unless a file with the same hash exists already. It returns the key of the
+// file and whether it existed. The key template is not applied, so the same
+// content is found on any day. The content is hashed while it is written to a
+// temporary file, as the key is needed before the upload, so it is not held
+// in memory but needs as much space in the temporary directory.
+func (s helper) CreateFileDedup(bucket, directory string, content io.Reader, mime string) (string, bool, error) {
+	if !s.Enabled {
+		return "", false, ErrServerDisabled
+	}
+
+	file, err := ioutil.TempFile("", "s3-dedup-")
+	if err != nil {
+		return "", false, errors.Wrap(err, "TempFile failed")
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), content)
+	if err != nil {
+		return "", false, errors.Wrap(err, "read content failed")
+	}
+
+	fileName := hex.EncodeToString(hash.Sum(nil))
+	key := filepath.Join(directory, fileName)
+
+	exists, err := s.objectExists(bucket, key)
+	if err != nil {
+		return "", false, errors.Wrap(err, "objectExists failed")
+	}
+	if exists {
+		return key, true, nil
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", false, errors.Wrap(err, "Seek failed")
+	}
+
+	err = s.putObject(bucket, key, file, size, minio.PutObjectOptions{ContentType: mime})
+	if err != nil {
+		return "", false, err
+	}
+
+	return key, false, nil
+}
@@ -0,0 +1,71 @@
+package s3
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDedup(t *testing.T) {
+	Convey("CreateFileDedup", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.CreateFileDedup("x43563", "dir", bytes.NewReader([]byte("asdf")), "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := newFakeS3()
+			defer server.Close()
+			s3 := newTestHelper(server.Server)
+
+			key, existed, err := s3.CreateFileDedup("x43563", "dir", bytes.NewReader([]byte("asdf")), "text/plain")
+			So(err, ShouldBeNil)
+			So(existed, ShouldBeFalse)
+			So(key, ShouldEqual, "dir/f0e4c2f76c58916ec258f246851bea091d14d4247a2fc3e18694461b1816e13b")
+			So(server.keys("x43563"), ShouldResemble, []string{key})
+			So(server.count("PUT"), ShouldEqual, 1)
+
+			obj, ok := server.get("x43563", key)
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+
+			key2, existed, err := s3.CreateFileDedup("x43563", "dir", bytes.NewReader([]byte("asdf")), "text/plain")
+			So(err, ShouldBeNil)
+			So(existed, ShouldBeTrue)
+			So(key2, ShouldEqual, key)
+			So(server.count("PUT"), ShouldEqual, 1)
+
+			key3, existed, err := s3.CreateFileDedup("x43563", "dir", bytes.NewReader([]byte("qwer")), "text/plain")
+			So(err, ShouldBeNil)
+			So(existed, ShouldBeFalse)
+			So(key3, ShouldNotEqual, key)
+			So(server.count("PUT"), ShouldEqual, 2)
+		})
+
+		Convey("Read error", func() {
+			server := newFakeS3()
+			defer server.Close()
+			s3 := newTestHelper(server.Server)
+
+			temporary := func() []string {
+				names, err := filepath.Glob(filepath.Join(os.TempDir(), "s3-dedup-*"))
+				So(err, ShouldBeNil)
+				return names
+			}
+			before := temporary()
+
+			_, _, err := s3.CreateFileDedup("x43563", "dir", iotest.TimeoutReader(bytes.NewReader([]byte("asdf"))), "text/plain")
+			So(err, ShouldNotBeNil)
+			So(server.received(), ShouldBeEmpty)
+			So(temporary(), ShouldResemble, before)
+		})
+	})
+}
@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"io"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// validateBuckets checks that the bucket aliases and names are not empty.
+func validateBuckets(value interface{}) error {
+	for alias, bucket := range value.(map[string]string) {
+		if alias == "" {
+			return errors.New("alias must not be empty")
+		}
+		if bucket == "" {
+			return errors.Errorf("bucket of %q must not be empty", alias)
+		}
+	}
+	return nil
+}
+
+// DefaultBucket returns the configured bucket, which is used by the *Default methods.
+func (s helper) DefaultBucket() string {
+	return s.Config.BucketName
+}
+
+// ResolveBucket returns the bucket configured for the logical name in
+// Config.Buckets.
+func (s helper) ResolveBucket(alias string) (string, error) {
+	bucket, ok := s.Config.Buckets[alias]
+	if !ok {
+		return "", errors.Errorf("unknown bucket alias %q", alias)
+	}
+	return bucket, nil
+}
+
+// CreateDirectoryDefault make new directory in the default bucket.
+func (s helper) CreateDirectoryDefault(name string) error {
+	return s.CreateDirectory(s.DefaultBucket(), name)
+}
+
+// CreateFileDefault make new file in specific directory in the default bucket.
+func (s helper) CreateFileDefault(directory, fileName string, content io.Reader, length int64, mime string, opts ...UploadOption) error {
+	return s.CreateFile(s.DefaultBucket(), directory, fileName, content, length, mime, opts...)
+}
+
+// GetFileDefault returns the file from the default bucket.
+func (s helper) GetFileDefault(directory, filename string) (*minio.Object, error) {
+	return s.GetFile(s.DefaultBucket(), directory, filename)
+}
+
+// FileExistsDefault returns the file exists in the default bucket or not.
+func (s helper) FileExistsDefault(directory, filename string) (bool, error) {
+	return s.FileExists(s.DefaultBucket(), directory, filename)
+}
+
+// RemoveDirectoryDefault removes the given directory from the default bucket.
+func (s helper) RemoveDirectoryDefault(directory string) error {
+	return s.RemoveDirectory(s.DefaultBucket(), directory)
+}
+
+// RemoveFileDefault removes the given file from the default bucket.
+func (s helper) RemoveFileDefault(directory, fileName string) error {
+	return s.RemoveFile(s.DefaultBucket(), directory, fileName)
+}
@@ -0,0 +1,123 @@
+package s3
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDefaultBucket(t *testing.T) {
+	Convey("DefaultBucket", t, func() {
+		s3 := helper{
+			Config: Config{BucketName: "x43563"},
+		}
+
+		So(s3.DefaultBucket(), ShouldEqual, "x43563")
+		So(s3.DefaultBucket(), ShouldEqual, s3.GetBucketName())
+	})
+
+	Convey("ResolveBucket", t, func() {
+		config := Config{
+			AccessKeyID:     "x",
+			Endpoint:        "localhost",
+			Region:          "x",
+			SecretAccessKey: "x",
+			BucketName:      "x43563",
+			Buckets: map[string]string{
+				"uploads":    "x43563-uploads",
+				"thumbnails": "x43563-thumbs",
+			},
+		}
+
+		Convey("Known", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			bucket, err := s3.ResolveBucket("uploads")
+			So(err, ShouldBeNil)
+			So(bucket, ShouldEqual, "x43563-uploads")
+
+			bucket, err = s3.ResolveBucket("thumbnails")
+			So(err, ShouldBeNil)
+			So(bucket, ShouldEqual, "x43563-thumbs")
+		})
+
+		Convey("Unknown", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			bucket, err := s3.ResolveBucket("avatars")
+			So(err, ShouldNotBeNil)
+			So(bucket, ShouldBeEmpty)
+		})
+
+		Convey("Invalid", func() {
+			config.Buckets["avatars"] = ""
+			_, err := New(config)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Default variants", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+
+		config := testConfig(server.Server)
+		config.BucketName = "default"
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CreateFileDefault("dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Default bucket", func() {
+			err := s3.CreateFileDefault("dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(server.keys("default"), ShouldResemble, []string{"dir/file.txt"})
+
+			exists, err := s3.FileExistsDefault("dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+
+			obj, err := s3.GetFileDefault("dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(obj, ShouldNotBeNil)
+
+			err = s3.RemoveFileDefault("dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(server.keys("default"), ShouldBeEmpty)
+		})
+
+		Convey("Explicit bucket", func() {
+			err := s3.CreateFile("other", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(server.keys("other"), ShouldResemble, []string{"dir/file.txt"})
+			So(server.keys("default"), ShouldBeEmpty)
+
+			exists, err := s3.FileExistsDefault("dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+
+			exists, err = s3.FileExists("other", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+		})
+
+		Convey("Directories", func() {
+			err := s3.CreateDirectoryDefault("dir")
+			So(err, ShouldBeNil)
+			So(server.keys("default"), ShouldResemble, []string{"dir/.created"})
+
+			err = s3.RemoveDirectoryDefault("dir/.created")
+			So(err, ShouldBeNil)
+			So(server.keys("default"), ShouldBeEmpty)
+		})
+	})
+}
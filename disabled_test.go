@@ -0,0 +1,400 @@
+package s3
+
+import (
+	"context"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDisabled(t *testing.T) {
+	Convey("Every method returns ErrServerDisabled", t, func() {
+		var s3 Helper = helper{
+			Enabled: false,
+		}
+
+		content := func() *strings.Reader {
+			return strings.NewReader("asdf")
+		}
+
+		methods := map[string]func() error{
+			"WithRegion": func() error {
+				_, err := s3.WithRegion("eu-west-1")
+				return err
+			},
+			"CreateBucket":    func() error { return s3.CreateBucket("x43563") },
+			"CreateDirectory": func() error { return s3.CreateDirectory("x43563", "dir") },
+			"CreateFile": func() error {
+				return s3.CreateFile("x43563", "dir", "file.txt", content(), 4, "text/plain")
+			},
+			"PutObject": func() error {
+				return s3.PutObject("x43563", "dir/file.txt", content(), 4, minio.PutObjectOptions{})
+			},
+			"CreateFileWithContext": func() error {
+				return s3.CreateFileWithContext(context.Background(), "x43563", "dir", "file.txt", content(), 4, "text/plain")
+			},
+			"CreateFileWithStorageClass": func() error {
+				return s3.CreateFileWithStorageClass("x43563", "dir", "file.txt", content(), 4, "text/plain", StorageClassStandard)
+			},
+			"CreateFileWithExpires": func() error {
+				return s3.CreateFileWithExpires("x43563", "dir", "file.txt", content(), 4, "text/plain", time.Now())
+			},
+			"CreateFileWithCacheControl": func() error {
+				return s3.CreateFileWithCacheControl("x43563", "dir", "file.txt", content(), 4, "text/plain", "no-cache")
+			},
+			"CreateFileAtomic": func() error {
+				return s3.CreateFileAtomic("x43563", "dir", "file.txt", content(), 4, "text/plain")
+			},
+			"GetOrCreateFile": func() error {
+				_, _, err := s3.GetOrCreateFile("x43563", "dir", "file.txt", content(), 4, "text/plain")
+				return err
+			},
+			"CreateFileIfNotExists": func() error {
+				_, err := s3.CreateFileIfNotExists("x43563", "dir", "file.txt", content(), 4, "text/plain")
+				return err
+			},
+			"CreateFileFromRequest": func() error {
+				return s3.CreateFileFromRequest("x43563", "dir", "file.txt", httptest.NewRequest("POST", "/", content()))
+			},
+			"CreateFileSeekable": func() error {
+				return s3.CreateFileSeekable("x43563", "dir", "file.txt", content(), 4, "text/plain")
+			},
+			"CreateFileStream": func() error {
+				return s3.CreateFileStream("x43563", "dir", "file.txt", content(), "text/plain")
+			},
+			"StartResumableUpload": func() error {
+				_, err := s3.StartResumableUpload("x43563", "dir", "file.txt", 4, "text/plain", nil)
+				return err
+			},
+			"ResumeUpload": func() error { return s3.ResumeUpload("id", content(), nil) },
+			"CreateFileSSEC": func() error {
+				return s3.CreateFileSSEC("x43563", "dir", "file.txt", content(), 4, "text/plain", make([]byte, 32))
+			},
+			"GetFileSSEC": func() error {
+				_, _, err := s3.GetFileSSEC("x43563", "dir", "file.txt", make([]byte, 32))
+				return err
+			},
+			"UploadDirectory": func() error {
+				_, err := s3.UploadDirectory("x43563", ".", "dir", 1)
+				return err
+			},
+			"DownloadDirectory": func() error {
+				_, err := s3.DownloadDirectory("x43563", "dir", ".", 1)
+				return err
+			},
+			"DownloadToFileParallel": func() error {
+				return s3.DownloadToFileParallel("x43563", "dir", "file.txt", "file.txt", 1)
+			},
+			"EnsureDirectoryMarkers": func() error {
+				_, err := s3.EnsureDirectoryMarkers("x43563", "dir")
+				return err
+			},
+			"GetObjectParts": func() error {
+				_, err := s3.GetObjectParts("x43563", "dir", "file.txt")
+				return err
+			},
+			"ListIncompleteUploads": func() error {
+				_, err := s3.ListIncompleteUploads("x43563", "dir")
+				return err
+			},
+			"AbortIncompleteUploads": func() error {
+				_, err := s3.AbortIncompleteUploads("x43563", "dir", time.Hour)
+				return err
+			},
+			"GetObjectLockConfig": func() error {
+				_, _, _, _, err := s3.GetObjectLockConfig("x43563")
+				return err
+			},
+			"SetObjectLockConfig": func() error {
+				return s3.SetObjectLockConfig("x43563", RetentionGovernance, 1, ValidityDays)
+			},
+			"BucketExists": func() error {
+				_, err := s3.BucketExists("x43563")
+				return err
+			},
+			"MustBucketExist": func() error { return s3.MustBucketExist("x43563") },
+			"ListBucketsDetailed": func() error {
+				_, err := s3.ListBucketsDetailed()
+				return err
+			},
+			"ListOfBucket": func() error {
+				_, err := s3.ListOfBucket()
+				return err
+			},
+			"BucketStats": func() error {
+				_, _, err := s3.BucketStats("x43563")
+				return err
+			},
+			"ListOfBucketFolder": func() error {
+				_, err := s3.ListOfBucketFolder("x43563", true)
+				return err
+			},
+			"ListOfBucketFolderWithContext": func() error {
+				_, err := s3.ListOfBucketFolderWithContext(context.Background(), "x43563", true)
+				return err
+			},
+			"GetFile": func() error {
+				_, err := s3.GetFile("x43563", "dir", "file.txt")
+				return err
+			},
+			"GetObjectRaw": func() error {
+				_, _, err := s3.GetObjectRaw("x43563", "dir/file.txt", minio.GetObjectOptions{})
+				return err
+			},
+			"Fetch": func() error {
+				_, err := s3.Fetch("x43563", "dir", "file.txt")
+				return err
+			},
+			"ServeFile": func() error {
+				return s3.ServeFile(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), "x43563", "dir", "file.txt")
+			},
+			"FileExists": func() error {
+				_, err := s3.FileExists("x43563", "dir", "file.txt")
+				return err
+			},
+			"FileExistsConsistent": func() error {
+				_, err := s3.FileExistsConsistent("x43563", "dir", "file.txt", 1, time.Millisecond)
+				return err
+			},
+			"VerifyFile": func() error {
+				_, err := s3.VerifyFile("x43563", "dir", "file.txt", strings.Repeat("0", 64))
+				return err
+			},
+			"RemoveBucket":    func() error { return s3.RemoveBucket("x43563") },
+			"RemoveDirectory": func() error { return s3.RemoveDirectory("x43563", "dir") },
+			"RemoveFile":      func() error { return s3.RemoveFile("x43563", "dir", "file.txt") },
+			"RestoreObject": func() error {
+				return s3.RestoreObject("x43563", "dir", "file.txt", 1, TierBulk)
+			},
+			"IsRestored": func() error {
+				_, err := s3.IsRestored("x43563", "dir", "file.txt")
+				return err
+			},
+			"StatFiles": func() error {
+				_, err := s3.StatFiles("x43563", []KeyRef{{Directory: "dir", FileName: "file.txt"}})
+				return err
+			},
+			"DiffPrefix": func() error {
+				_, _, _, err := s3.DiffPrefix("x43563", "a/", "x43563", "b/")
+				return err
+			},
+			"SyncPrefix": func() error {
+				_, err := s3.SyncPrefix("x43563", "src", "x43563", "dst", false)
+				return err
+			},
+			"GetETag": func() error {
+				_, _, err := s3.GetETag("x43563", "dir", "file.txt")
+				return err
+			},
+			"GetUserMetadata": func() error {
+				_, err := s3.GetUserMetadata("x43563", "dir", "file.txt")
+				return err
+			},
+			"GetLastModified": func() error {
+				_, _, err := s3.GetLastModified("x43563", "dir", "file.txt")
+				return err
+			},
+			"SetBucketReplication": func() error {
+				return s3.SetBucketReplication("x43563", ReplicationConfig{})
+			},
+			"GetBucketReplication": func() error {
+				_, err := s3.GetBucketReplication("x43563")
+				return err
+			},
+			"AddReplicationRule": func() error {
+				return s3.AddReplicationRule("x43563", "rule", "dir", "arn:aws:s3:::backup")
+			},
+			"SetBucketEncryption": func() error {
+				return s3.SetBucketEncryption("x43563", SSEAlgorithmAES256, "")
+			},
+			"GetBucketEncryption": func() error {
+				_, _, err := s3.GetBucketEncryption("x43563")
+				return err
+			},
+			"SetBucketWebsite": func() error {
+				return s3.SetBucketWebsite("x43563", "index.html", "error.html")
+			},
+			"GetBucketWebsite": func() error {
+				_, err := s3.GetBucketWebsite("x43563")
+				return err
+			},
+			"VerifyCredentials": func() error { return s3.VerifyCredentials(context.Background()) },
+			"ListFileVersions": func() error {
+				_, err := s3.ListFileVersions("x43563", "dir")
+				return err
+			},
+			"BackendType": func() error {
+				_, err := s3.BackendType()
+				return err
+			},
+			"DeleteObjectVersion": func() error {
+				return s3.DeleteObjectVersion("x43563", "dir", "file.txt", "v1")
+			},
+			"ListDeleteMarkers": func() error {
+				_, err := s3.ListDeleteMarkers("x43563", "dir/")
+				return err
+			},
+			"PurgeDeleteMarkers": func() error {
+				_, err := s3.PurgeDeleteMarkers("x43563", "dir/")
+				return err
+			},
+			"PruneVersions": func() error { return s3.PruneVersions("x43563", "dir", 1) },
+			"SelectCSV": func() error {
+				_, err := s3.SelectCSV("x43563", "dir", "file.csv", "SELECT * FROM S3Object")
+				return err
+			},
+			"GetFileLines": func() error {
+				_, _, err := s3.GetFileLines("x43563", "dir", "file.txt")
+				return err
+			},
+			"GetFileResilient": func() error {
+				_, _, err := s3.GetFileResilient("x43563", "dir", "file.txt")
+				return err
+			},
+			"GetFileDecoded": func() error {
+				_, _, err := s3.GetFileDecoded("x43563", "dir", "file.txt")
+				return err
+			},
+			"GetFileSniffed": func() error {
+				_, _, _, err := s3.GetFileSniffed("x43563", "dir", "file.txt")
+				return err
+			},
+			"CreateFileCompressed": func() error {
+				return s3.CreateFileCompressed("x43563", "dir", "file.txt", content(), "text/plain")
+			},
+			"CreateImageFile": func() error {
+				return s3.CreateImageFile("x43563", "dir", "image.jpg", content(), "image/jpeg")
+			},
+			"CreateFileDedup": func() error {
+				_, _, err := s3.CreateFileDedup("x43563", "dir", content(), "text/plain")
+				return err
+			},
+			"BrowseDirectory": func() error {
+				_, _, _, err := s3.BrowseDirectory("x43563", "dir/", SortByName, true, 0, 0)
+				return err
+			},
+			"GenerateManifest": func() error {
+				_, err := s3.GenerateManifest("x43563", "dir/")
+				return err
+			},
+			"WriteManifest": func() error {
+				return s3.WriteManifest("x43563", "dir/")
+			},
+			"ListFilesAfter": func() error {
+				_, err := s3.ListFilesAfter("x43563", "dir/", "dir/a.txt", true)
+				return err
+			},
+			"GlobFiles": func() error {
+				_, err := s3.GlobFiles("x43563", "images/*.png")
+				return err
+			},
+			"ListFilesModifiedSince": func() error {
+				_, err := s3.ListFilesModifiedSince("x43563", "dir/", time.Now())
+				return err
+			},
+			"CopyFileWithTags": func() error {
+				src := SourceRef{Bucket: "x43563", Directory: "dir", FileName: "a.txt"}
+				dst := SourceRef{Bucket: "x43563", Directory: "dir", FileName: "b.txt"}
+				return s3.CopyFileWithTags(src, dst, nil, false)
+			},
+			"CloneFile": func() error {
+				src := SourceRef{Bucket: "x43563", Directory: "dir", FileName: "a.txt"}
+				dst := SourceRef{Bucket: "x43563", Directory: "dir", FileName: "b.txt"}
+				return s3.CloneFile(src, dst)
+			},
+			"RenameFile": func() error {
+				return s3.RenameFile("x43563", "dir", "file.txt", "other.txt")
+			},
+			"ChangeStorageClass": func() error {
+				return s3.ChangeStorageClass("x43563", "dir", "file.txt", StorageClassGlacier)
+			},
+			"TouchFile": func() error {
+				return s3.TouchFile("x43563", "dir", "file.txt")
+			},
+			"SetRedirect": func() error {
+				return s3.SetRedirect("x43563", "dir", "file.html", "/new.html")
+			},
+			"MakePrefixPublicRead": func() error { return s3.MakePrefixPublicRead("x43563", "dir") },
+			"UploadGrant": func() error {
+				_, err := s3.UploadGrant("x43563", "users/1/", 1<<20, "image/", time.Hour)
+				return err
+			},
+			"UpdateBucketPolicy": func() error {
+				return s3.UpdateBucketPolicy("x43563", func(policy *BucketPolicyDoc) error { return nil })
+			},
+			"NewObjectReaderAt": func() error {
+				_, _, err := s3.NewObjectReaderAt("x43563", "dir", "file.txt")
+				return err
+			},
+			"TrashFile":        func() error { return s3.TrashFile("x43563", "dir", "file.txt") },
+			"RestoreFromTrash": func() error { return s3.RestoreFromTrash("x43563", "dir", "file.txt") },
+			"PresignedGetURLs": func() error {
+				_, err := s3.PresignedGetURLs("x43563", []KeyRef{{Directory: "dir", FileName: "file.txt"}}, time.Hour)
+				return err
+			},
+			"RestrictPresignedSourceIP": func() error {
+				return s3.RestrictPresignedSourceIP("x43563", "dir/", "203.0.113.7")
+			},
+			"PresignedGetURLFromIP": func() error {
+				_, err := s3.PresignedGetURLFromIP("x43563", "dir", "file.txt", time.Hour, "203.0.113.7")
+				return err
+			},
+			"PresignedDownloadURL": func() error {
+				_, err := s3.PresignedDownloadURL("x43563", "dir", "file.txt", "report.txt", time.Hour)
+				return err
+			},
+			"PresignedHeadURL": func() error {
+				_, err := s3.PresignedHeadURL("x43563", "dir", "file.txt", time.Hour)
+				return err
+			},
+			"PresignedGetURLString": func() error {
+				_, err := s3.PresignedGetURLString("x43563", "dir", "file.txt", time.Hour)
+				return err
+			},
+			"PublicURL": func() error {
+				_, err := s3.PublicURL("x43563", "dir", "file.txt")
+				return err
+			},
+			"PublicURLString": func() error {
+				_, err := s3.PublicURLString("x43563", "dir", "file.txt")
+				return err
+			},
+			"PresignedGetURLWithHeaders": func() error {
+				_, err := s3.PresignedGetURLWithHeaders("x43563", "dir", "file.txt", time.Hour, nil)
+				return err
+			},
+			"CreateDirectoryDefault": func() error { return s3.CreateDirectoryDefault("dir") },
+			"CreateFileDefault": func() error {
+				return s3.CreateFileDefault("dir", "file.txt", content(), 4, "text/plain")
+			},
+			"GetFileDefault": func() error {
+				_, err := s3.GetFileDefault("dir", "file.txt")
+				return err
+			},
+			"FileExistsDefault": func() error {
+				_, err := s3.FileExistsDefault("dir", "file.txt")
+				return err
+			},
+			"RemoveDirectoryDefault": func() error { return s3.RemoveDirectoryDefault("dir") },
+			"RemoveFileDefault":      func() error { return s3.RemoveFileDefault("dir", "file.txt") },
+		}
+
+		names := []string{}
+		for name := range methods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			method := methods[name]
+			Convey(name, func() {
+				So(method(), ShouldEqual, ErrServerDisabled)
+			})
+		}
+	})
+}
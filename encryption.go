@@ -0,0 +1,104 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Algorithms of the default encryption of the buckets.
+const (
+	SSEAlgorithmAES256 = "AES256"
+	SSEAlgorithmKMS    = "aws:kms"
+)
+
+// bucketEncryptionConfig represents the default encryption configuration of
+// a bucket.
+type bucketEncryptionConfig struct {
+	XMLName xml.Name               `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ServerSideEncryptionConfiguration"`
+	Rules   []bucketEncryptionRule `xml:"Rule"`
+}
+
+// bucketEncryptionRule is a rule of the default encryption.
+type bucketEncryptionRule struct {
+	ApplyServerSideEncryptionByDefault struct {
+		SSEAlgorithm   string `xml:"SSEAlgorithm"`
+		KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty"`
+	} `xml:"ApplyServerSideEncryptionByDefault"`
+}
+
+// SetBucketEncryption sets the default encryption of the bucket, so the
+// objects uploaded without encryption options are encrypted at rest too. The
+// KMS key id is only used with aws:kms, empty means the default KMS key.
+func (s helper) SetBucketEncryption(bucket, sseAlgorithm, kmsKeyID string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validation.Validate(sseAlgorithm, validation.Required, validation.In(SSEAlgorithmAES256, SSEAlgorithmKMS))
+	if err != nil {
+		return errors.Wrap(err, "invalid algorithm")
+	}
+	if kmsKeyID != "" && sseAlgorithm != SSEAlgorithmKMS {
+		return errors.New("a KMS key can only be used with aws:kms")
+	}
+
+	rule := bucketEncryptionRule{}
+	rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm = sseAlgorithm
+	rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID = kmsKeyID
+	config := bucketEncryptionConfig{Rules: []bucketEncryptionRule{rule}}
+
+	content, err := xml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "xml.Marshal failed")
+	}
+
+	resp, err := s.executeMethod(context.Background(), "PUT", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"encryption": {""}},
+		content:     content,
+	})
+	if err != nil {
+		return errors.Wrap(err, "SetBucketEncryption failed")
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// GetBucketEncryption returns the algorithm and the KMS key id of the default
+// encryption of the bucket. The algorithm is empty if the bucket has no
+// default encryption.
+func (s helper) GetBucketEncryption(bucket string) (string, string, error) {
+	if !s.Enabled {
+		return "", "", ErrServerDisabled
+	}
+
+	resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"encryption": {""}},
+	})
+	if minio.ToErrorResponse(err).Code == "ServerSideEncryptionConfigurationNotFoundError" {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", errors.Wrap(err, "GetBucketEncryption failed")
+	}
+	defer resp.Body.Close()
+
+	config := bucketEncryptionConfig{}
+	err = xml.NewDecoder(resp.Body).Decode(&config)
+	if err != nil {
+		return "", "", errors.Wrap(err, "xml.Decode failed")
+	}
+	if len(config.Rules) == 0 {
+		return "", "", nil
+	}
+
+	rule := config.Rules[0].ApplyServerSideEncryptionByDefault
+	return rule.SSEAlgorithm, rule.KMSMasterKeyID, nil
+}
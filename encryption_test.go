@@ -0,0 +1,100 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBucketEncryption(t *testing.T) {
+	Convey("SetBucketEncryption", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.SetBucketEncryption("x43563", SSEAlgorithmAES256, "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid settings", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			So(s3.SetBucketEncryption("x43563", "", ""), ShouldNotBeNil)
+			So(s3.SetBucketEncryption("x43563", "DES", ""), ShouldNotBeNil)
+			So(s3.SetBucketEncryption("x43563", SSEAlgorithmAES256, "key"), ShouldNotBeNil)
+		})
+
+		var method, body string
+		var query map[string][]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			query = r.URL.Query()
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+		}))
+		defer server.Close()
+		s3 := newTestHelper(server)
+
+		Convey("AES256", func() {
+			err := s3.SetBucketEncryption("x43563", SSEAlgorithmAES256, "")
+			So(err, ShouldBeNil)
+			So(method, ShouldEqual, "PUT")
+			So(query, ShouldContainKey, "encryption")
+			So(body, ShouldContainSubstring, "<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule>")
+		})
+
+		Convey("KMS", func() {
+			err := s3.SetBucketEncryption("x43563", SSEAlgorithmKMS, "arn:aws:kms:eu-west-1:123456789012:key/abc")
+			So(err, ShouldBeNil)
+			So(body, ShouldContainSubstring, "<SSEAlgorithm>aws:kms</SSEAlgorithm>")
+			So(body, ShouldContainSubstring, "<KMSMasterKeyID>arn:aws:kms:eu-west-1:123456789012:key/abc</KMSMasterKeyID>")
+		})
+	})
+
+	Convey("GetBucketEncryption", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetBucketEncryption("x43563")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<ServerSideEncryptionConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+					`<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>aws:kms</SSEAlgorithm>` +
+					`<KMSMasterKeyID>key</KMSMasterKeyID></ApplyServerSideEncryptionByDefault></Rule>` +
+					`</ServerSideEncryptionConfiguration>`))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			algorithm, keyID, err := s3.GetBucketEncryption("x43563")
+			So(err, ShouldBeNil)
+			So(algorithm, ShouldEqual, SSEAlgorithmKMS)
+			So(keyID, ShouldEqual, "key")
+		})
+
+		Convey("Not configured", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("<Error><Code>ServerSideEncryptionConfigurationNotFoundError</Code></Error>"))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			algorithm, keyID, err := s3.GetBucketEncryption("x43563")
+			So(err, ShouldBeNil)
+			So(algorithm, ShouldBeEmpty)
+			So(keyID, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package s3
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the server rejects the credentials.
+	ErrInvalidCredentials = errors.New("s3: invalid credentials")
+
+	// ErrUnreachable is returned when the server can not be reached.
+	ErrUnreachable = errors.New("s3: server is unreachable")
+
+	// ErrServerDisabled is returned by every method which would send a
+	// request when the server is not enabled.
+	ErrServerDisabled = errors.New("s3: server is not enabled")
+
+	// ErrNoSuchBucket is returned when the bucket does not exist.
+	ErrNoSuchBucket = errors.New("s3: bucket does not exist")
+)
+
+// BatchError holds the errors of the failed items of a batch operation, keyed
+// by the item.
+type BatchError map[string]error
+
+// Error implements error.
+func (e BatchError) Error() string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, key+": "+e[key].Error())
+	}
+	return "s3: " + strings.Join(messages, "; ")
+}
+
+// AbortedUploadError is returned when a multipart upload fails after it was
+// started. AbortErr is nil if the uploaded parts were removed from the server,
+// otherwise the upload is left incomplete and can be aborted later with
+// AbortIncompleteUploads.
+type AbortedUploadError struct {
+	Err      error
+	UploadID string
+	AbortErr error
+}
+
+// Error implements error.
+func (e *AbortedUploadError) Error() string {
+	if e.AbortErr != nil {
+		return e.Err.Error() + " (abort failed: " + e.AbortErr.Error() + ")"
+	}
+	return e.Err.Error()
+}
+
+// Cause returns the error which failed the upload.
+func (e *AbortedUploadError) Cause() error {
+	return e.Err
+}
@@ -0,0 +1,561 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// headerSSECKeyMD5 is the checksum of the customer provided encryption key.
+const headerSSECKeyMD5 = "X-Amz-Server-Side-Encryption-Customer-Key-Md5"
+
+// fakeObject is an object stored by the fake S3 server.
+type fakeObject struct {
+	data         []byte
+	etag         string
+	header       http.Header
+	tags         url.Values
+	acl          string
+	lastModified time.Time
+}
+
+// fakeUpload is an incomplete multipart upload of the fake S3 server.
+type fakeUpload struct {
+	bucket    string
+	key       string
+	header    http.Header
+	parts     map[int][]byte
+	initiated time.Time
+}
+
+// fakeRequest is a request received by the fake S3 server.
+type fakeRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+}
+
+// fakeS3 is an in-memory S3 server for the tests.
+type fakeS3 struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string]*fakeObject
+	uploads  map[string]*fakeUpload
+	policies map[string]string
+	requests []fakeRequest
+	nextID   int
+
+	// failures are the error codes returned to the next requests of the
+	// methods, after their body was read.
+	failures map[string][]string
+}
+
+// storedHeaders are the request headers which are stored with the objects.
+var storedHeaders = []string{
+	"Content-Type",
+	"Content-Encoding",
+	"Content-Disposition",
+	"Content-Language",
+	"Cache-Control",
+	"Expires",
+	"X-Amz-Storage-Class",
+	"X-Amz-Website-Redirect-Location",
+	"X-Amz-Server-Side-Encryption",
+	"X-Amz-Server-Side-Encryption-Customer-Key-Md5",
+}
+
+// newFakeS3 starts a new fake S3 server.
+func newFakeS3() *fakeS3 {
+	f := &fakeS3{
+		objects:  map[string]*fakeObject{},
+		uploads:  map[string]*fakeUpload{},
+		policies: map[string]string{},
+		failures: map[string][]string{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+// fail makes the next requests of the method fail with the error codes.
+func (f *fakeS3) fail(method string, codes ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method] = append(f.failures[method], codes...)
+}
+
+// put stores an object in the fake server.
+func (f *fakeS3) put(bucket, key string, data []byte, header http.Header) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.store(bucket, key, data, header)
+}
+
+// startUpload starts a multipart upload with the given parts in the fake
+// server and returns its id.
+func (f *fakeS3) startUpload(bucket, key string, initiated time.Time, parts ...[]byte) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	id := strconv.Itoa(f.nextID)
+	upload := &fakeUpload{bucket: bucket, key: key, header: http.Header{}, parts: map[int][]byte{}, initiated: initiated.UTC()}
+	for i, part := range parts {
+		upload.parts[i+1] = part
+	}
+	f.uploads[id] = upload
+	return id
+}
+
+// uploadIDs returns the sorted ids of the incomplete uploads.
+func (f *fakeS3) uploadIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := []string{}
+	for id := range f.uploads {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// policy returns the bucket policy.
+func (f *fakeS3) policy(bucket string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.policies[bucket]
+}
+
+// setPolicy sets the bucket policy.
+func (f *fakeS3) setPolicy(bucket, policy string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.policies[bucket] = policy
+}
+
+// get returns a stored object.
+func (f *fakeS3) get(bucket, key string) (*fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[bucket+"/"+key]
+	return obj, ok
+}
+
+// keys returns the sorted keys stored in the bucket.
+func (f *fakeS3) keys(bucket string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := []string{}
+	for k := range f.objects {
+		if strings.HasPrefix(k, bucket+"/") {
+			keys = append(keys, strings.TrimPrefix(k, bucket+"/"))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// count returns the number of received requests with the given method.
+func (f *fakeS3) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, r := range f.requests {
+		if r.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// received returns the received requests.
+func (f *fakeS3) received() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.requests...)
+}
+
+func (f *fakeS3) store(bucket, key string, data []byte, header http.Header) *fakeObject {
+	h := http.Header{}
+	for k, v := range header {
+		k = http.CanonicalHeaderKey(k)
+		if strings.HasPrefix(k, "X-Amz-Meta-") {
+			h[k] = v
+		}
+	}
+	for _, k := range storedHeaders {
+		if v := header.Get(k); v != "" {
+			h.Set(k, v)
+		}
+	}
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", "application/octet-stream")
+	}
+
+	tags, _ := url.ParseQuery(header.Get("X-Amz-Tagging"))
+
+	sum := md5.Sum(data)
+	obj := &fakeObject{
+		data:         data,
+		etag:         hex.EncodeToString(sum[:]),
+		header:       h,
+		tags:         tags,
+		lastModified: time.Now().UTC().Truncate(time.Second),
+	}
+	f.objects[bucket+"/"+key] = obj
+	return obj
+}
+
+func (f *fakeS3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, fakeRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header,
+	})
+
+	if codes := f.failures[r.Method]; len(codes) > 0 {
+		f.failures[r.Method] = codes[1:]
+		readFakeBody(r)
+		writeFakeError(w, http.StatusServiceUnavailable, codes[0])
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		bucket, key = path[:i], path[i+1:]
+	}
+	query := r.URL.Query()
+
+	if key == "" {
+		switch {
+		case r.Method == "GET" && query.Get("list-type") == "2":
+			f.list(w, bucket, query)
+		case r.Method == "GET" && has(query, "uploads"):
+			f.listUploads(w, bucket, query)
+		case r.Method == "GET" && has(query, "policy"):
+			policy, ok := f.policies[bucket]
+			if !ok {
+				writeFakeError(w, http.StatusNotFound, "NoSuchBucketPolicy")
+				return
+			}
+			w.Write([]byte(policy))
+		case r.Method == "PUT" && has(query, "policy"):
+			f.policies[bucket] = string(readFakeBody(r))
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "DELETE" && has(query, "policy"):
+			delete(f.policies, bucket)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	switch {
+	case r.Method == "POST" && has(query, "uploads"):
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.uploads[id] = &fakeUpload{bucket: bucket, key: key, header: r.Header, parts: map[int][]byte{}, initiated: time.Now().UTC()}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", bucket, key, id)
+	case r.Method == "PUT" && query.Get("uploadId") != "":
+		upload, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		data := readFakeBody(r)
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			source, _ := url.QueryUnescape(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+			src, ok := f.objects[source]
+			if !ok {
+				writeFakeError(w, http.StatusNotFound, "NoSuchKey")
+				return
+			}
+			var start, end int
+			fmt.Sscanf(r.Header.Get("X-Amz-Copy-Source-Range"), "bytes=%d-%d", &start, &end)
+			data = src.data[start : end+1]
+		}
+		upload.parts[n] = data
+		sum := md5.Sum(data)
+		if r.Header.Get("X-Amz-Copy-Source") != "" {
+			fmt.Fprintf(w, `<CopyPartResult><ETag>"%s"</ETag></CopyPartResult>`, hex.EncodeToString(sum[:]))
+			return
+		}
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	case r.Method == "POST" && query.Get("uploadId") != "":
+		upload, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+			} `xml:"Part"`
+		}
+		xml.NewDecoder(r.Body).Decode(&complete)
+		var data []byte
+		for _, part := range complete.Parts {
+			data = append(data, upload.parts[part.PartNumber]...)
+		}
+		obj := f.store(upload.bucket, upload.key, data, upload.header)
+		obj.etag = fmt.Sprintf("%s-%d", obj.etag, len(complete.Parts))
+		delete(f.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"%s"</ETag></CompleteMultipartUploadResult>`, bucket, key, obj.etag)
+	case r.Method == "GET" && query.Get("uploadId") != "":
+		upload, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		numbers := []int{}
+		for n := range upload.parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "<ListPartsResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId><IsTruncated>false</IsTruncated>", bucket, key, query.Get("uploadId"))
+		for _, n := range numbers {
+			sum := md5.Sum(upload.parts[n])
+			fmt.Fprintf(&buf, `<Part><PartNumber>%d</PartNumber><ETag>"%s"</ETag><Size>%d</Size></Part>`, n, hex.EncodeToString(sum[:]), len(upload.parts[n]))
+		}
+		buf.WriteString("</ListPartsResult>")
+		w.Write(buf.Bytes())
+	case r.Method == "DELETE" && query.Get("uploadId") != "":
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "PUT" && r.Header.Get("X-Amz-Copy-Source") != "":
+		source, _ := url.QueryUnescape(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+		src, ok := f.objects[source]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		header := src.header
+		if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+			header = r.Header
+		}
+		obj := f.store(bucket, key, src.data, header)
+		obj.tags = src.tags
+		if r.Header.Get("X-Amz-Tagging-Directive") == "REPLACE" {
+			obj.tags, _ = url.ParseQuery(r.Header.Get("X-Amz-Tagging"))
+		}
+		if class := r.Header.Get("X-Amz-Storage-Class"); class != "" {
+			obj.header.Set("X-Amz-Storage-Class", class)
+		}
+		fmt.Fprintf(w, `<CopyObjectResult><LastModified>%s</LastModified><ETag>"%s"</ETag></CopyObjectResult>`, obj.lastModified.Format(time.RFC3339), obj.etag)
+	case has(query, "tagging") || has(query, "acl"):
+		obj, ok := f.objects[bucket+"/"+key]
+		if !ok {
+			writeFakeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		f.serveSubresource(w, r, obj)
+	case r.Method == "PUT":
+		f.store(bucket, key, readFakeBody(r), r.Header)
+	case r.Method == "GET" || r.Method == "HEAD":
+		obj, ok := f.objects[bucket+"/"+key]
+		if !ok {
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeFakeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if obj.header.Get(headerSSECKeyMD5) != r.Header.Get(headerSSECKeyMD5) {
+			writeFakeError(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		f.serveObject(w, r, obj)
+	case r.Method == "DELETE":
+		delete(f.objects, bucket+"/"+key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// serveSubresource serves the tags and the ACL of the object.
+func (f *fakeS3) serveSubresource(w http.ResponseWriter, r *http.Request, obj *fakeObject) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == "GET" && has(query, "tagging"):
+		keys := []string{}
+		for k := range obj.tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var buf bytes.Buffer
+		buf.WriteString("<Tagging><TagSet>")
+		for _, k := range keys {
+			fmt.Fprintf(&buf, "<Tag><Key>%s</Key><Value>%s</Value></Tag>", k, obj.tags.Get(k))
+		}
+		buf.WriteString("</TagSet></Tagging>")
+		w.Write(buf.Bytes())
+	case r.Method == "GET":
+		acl := obj.acl
+		if acl == "" {
+			acl = `<AccessControlPolicy><Owner><ID>owner</ID></Owner><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>owner</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant></AccessControlList></AccessControlPolicy>`
+		}
+		w.Write([]byte(acl))
+	case r.Method == "PUT" && has(query, "acl"):
+		obj.acl = string(readFakeBody(r))
+	}
+}
+
+func (f *fakeS3) serveObject(w http.ResponseWriter, r *http.Request, obj *fakeObject) {
+	for k, v := range obj.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("ETag", `"`+obj.etag+`"`)
+	w.Header().Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+
+	data := obj.data
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		start, end := parseFakeRange(rng, int64(len(data)))
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == "GET" {
+		w.Write(data)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, bucket string, query url.Values) {
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	startAfter := query.Get("start-after")
+
+	keys := []string{}
+	for k := range f.objects {
+		if strings.HasPrefix(k, bucket+"/"+prefix) {
+			keys = append(keys, strings.TrimPrefix(k, bucket+"/"))
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+	fmt.Fprintf(&buf, "<Name>%s</Name><Prefix>%s</Prefix><IsTruncated>false</IsTruncated>", bucket, prefix)
+	prefixes := map[string]bool{}
+	for _, k := range keys {
+		if startAfter != "" && k <= startAfter {
+			continue
+		}
+		rest := strings.TrimPrefix(k, prefix)
+		if delimiter != "" && strings.Contains(rest, delimiter) {
+			p := prefix + rest[:strings.Index(rest, delimiter)+len(delimiter)]
+			if !prefixes[p] {
+				prefixes[p] = true
+				buf.WriteString("<CommonPrefixes><Prefix>")
+				xml.EscapeText(&buf, []byte(p))
+				buf.WriteString("</Prefix></CommonPrefixes>")
+			}
+			continue
+		}
+		obj := f.objects[bucket+"/"+k]
+		buf.WriteString("<Contents><Key>")
+		xml.EscapeText(&buf, []byte(k))
+		fmt.Fprintf(&buf, `</Key><LastModified>%s</LastModified><ETag>"%s"</ETag><Size>%d</Size><StorageClass>STANDARD</StorageClass></Contents>`,
+			obj.lastModified.Format(time.RFC3339), obj.etag, len(obj.data))
+	}
+	buf.WriteString("</ListBucketResult>")
+	w.Write(buf.Bytes())
+}
+
+func (f *fakeS3) listUploads(w http.ResponseWriter, bucket string, query url.Values) {
+	ids := []string{}
+	for id, upload := range f.uploads {
+		if upload.bucket == bucket && strings.HasPrefix(upload.key, query.Get("prefix")) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<ListMultipartUploadsResult><Bucket>%s</Bucket><IsTruncated>false</IsTruncated>", bucket)
+	for _, id := range ids {
+		upload := f.uploads[id]
+		fmt.Fprintf(&buf, "<Upload><Key>%s</Key><UploadId>%s</UploadId><Initiated>%s</Initiated></Upload>",
+			upload.key, id, upload.initiated.Format(time.RFC3339))
+	}
+	buf.WriteString("</ListMultipartUploadsResult>")
+	w.Write(buf.Bytes())
+}
+
+func has(query url.Values, key string) bool {
+	_, ok := query[key]
+	return ok
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func parseFakeRange(rng string, size int64) (int64, int64) {
+	rng = strings.TrimPrefix(rng, "bytes=")
+	parts := strings.SplitN(rng, "-", 2)
+	if parts[0] == "" {
+		n, _ := strconv.ParseInt(parts[1], 10, 64)
+		return size - n, size - 1
+	}
+	start, _ := strconv.ParseInt(parts[0], 10, 64)
+	end := size - 1
+	if parts[1] != "" {
+		end, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	if end > size-1 {
+		end = size - 1
+	}
+	return start, end
+}
+
+// readFakeBody reads the request body, decoding the aws-chunked encoding
+// used by the streaming signature.
+func readFakeBody(r *http.Request) []byte {
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		data, _ := ioutil.ReadAll(r.Body)
+		return data
+	}
+
+	var data []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return data
+		}
+		size, _ := strconv.ParseInt(strings.SplitN(strings.TrimSpace(line), ";", 2)[0], 16, 64)
+		if size == 0 {
+			return data
+		}
+		chunk := make([]byte, size)
+		io.ReadFull(reader, chunk)
+		data = append(data, chunk...)
+		reader.ReadString('\n')
+	}
+}
@@ -0,0 +1,39 @@
+package s3
+
+import (
+	"io"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+)
+
+// FetchResult is a file read by Fetch.
+type FetchResult struct {
+	// Found is false if the file does not exist, the other fields are empty
+	// then.
+	Found bool
+	Info  minio.ObjectInfo
+	// Body is the content of the file, the caller must close it.
+	Body io.ReadCloser
+}
+
+// Fetch returns the object info and the content of the file together.
+func (s helper) Fetch(bucket, directory, filename string) (*FetchResult, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	obj, info, found, err := s.openObject(bucket, filepath.Join(directory, filename), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &FetchResult{}, nil
+	}
+
+	return &FetchResult{
+		Found: true,
+		Info:  info,
+		Body:  obj,
+	}, nil
+}
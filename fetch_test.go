@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFetch(t *testing.T) {
+	Convey("Fetch", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.Fetch("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), http.Header{
+			"Content-Type":     {"text/plain"},
+			"X-Amz-Meta-Owner": {"alice"},
+		})
+		s3 := newTestHelper(server.Server)
+
+		Convey("Success", func() {
+			result, err := s3.Fetch("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(result.Found, ShouldBeTrue)
+			So(result.Info.Key, ShouldEqual, "dir/file.txt")
+			So(result.Info.Size, ShouldEqual, 4)
+			So(result.Info.ContentType, ShouldEqual, "text/plain")
+			So(result.Info.ETag, ShouldNotBeEmpty)
+			So(result.Info.Metadata.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+
+			data, err := ioutil.ReadAll(result.Body)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "asdf")
+			So(result.Body.Close(), ShouldBeNil)
+		})
+
+		Convey("Missing file", func() {
+			result, err := s3.Fetch("x43563", "dir", "missing.txt")
+			So(err, ShouldBeNil)
+			So(result.Found, ShouldBeFalse)
+			So(result.Body, ShouldBeNil)
+		})
+	})
+}
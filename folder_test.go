@@ -0,0 +1,152 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBuildFolderTree(t *testing.T) {
+	Convey("BuildFolderTree", t, func() {
+		Convey("Empty", func() {
+			root := BuildFolderTree("x43563", nil)
+			So(root, ShouldResemble, &Folder{Name: "x43563"})
+		})
+
+		Convey("Flat", func() {
+			root := BuildFolderTree("x43563", []string{"a.txt", "b.txt"})
+			So(root.Name, ShouldEqual, "x43563")
+			So(root.Folders, ShouldHaveLength, 2)
+			So(root.Get("a.txt").Name, ShouldEqual, "a.txt")
+			So(root.Get("b.txt").Folders, ShouldBeNil)
+		})
+
+		Convey("Deep nesting", func() {
+			root := BuildFolderTree("x43563", []string{"a/b/c/d/e.txt", "a/b/f.txt", "a/g.txt"})
+			So(root.Folders, ShouldHaveLength, 1)
+			So(root.Get("a").Folders, ShouldHaveLength, 2)
+			So(root.Get("a", "b").Folders, ShouldHaveLength, 2)
+			So(root.Get("a", "b", "c", "d", "e.txt").Name, ShouldEqual, "e.txt")
+			So(root.Get("a", "b", "f.txt"), ShouldNotBeNil)
+			So(root.Get("a", "g.txt"), ShouldNotBeNil)
+		})
+
+		Convey("Shared prefix after a deeper key", func() {
+			// A later key must not replace the folders of an earlier one.
+			root := BuildFolderTree("x43563", []string{"a/b/c.txt", "a/d.txt"})
+			So(root.Get("a", "b", "c.txt"), ShouldNotBeNil)
+			So(root.Get("a", "d.txt"), ShouldNotBeNil)
+		})
+
+		Convey("Trailing slashes", func() {
+			root := BuildFolderTree("x43563", []string{"a/", "a/b/", "c//d.txt", "/e.txt"})
+			So(root.Folders, ShouldHaveLength, 3)
+			So(root.Get("a").Folders, ShouldHaveLength, 1)
+			So(root.Get("a", "b").Folders, ShouldBeNil)
+			So(root.Get("c", "d.txt"), ShouldNotBeNil)
+			So(root.Get("e.txt"), ShouldNotBeNil)
+		})
+
+		Convey("Duplicates", func() {
+			root := BuildFolderTree("x43563", []string{"a/b.txt", "a/b.txt", "a/", "a/b.txt"})
+			So(root, ShouldResemble, BuildFolderTree("x43563", []string{"a/b.txt"}))
+		})
+	})
+
+	Convey("ListOfBucketFolder", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		for _, key := range []string{"a/b/c.txt", "a/d.txt", "e.txt"} {
+			server.put("x43563", key, []byte("asdf"), nil)
+		}
+		s3 := newTestHelper(server.Server)
+
+		root, err := s3.ListOfBucketFolder("x43563", true)
+		So(err, ShouldBeNil)
+		So(root, ShouldResemble, BuildFolderTree("x43563", []string{"a/b/c.txt", "a/d.txt", "e.txt"}))
+	})
+
+	Convey("ListOfBucketFolder WithFileCounts", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		for _, key := range []string{"a/b/c.txt", "a/b/d.txt", "a/e.txt", "a/f/", "g.txt", "h.txt", "i.txt"} {
+			server.put("x43563", key, []byte("asdf"), nil)
+		}
+		s3 := newTestHelper(server.Server)
+
+		Convey("Recursive", func() {
+			root, err := s3.ListOfBucketFolder("x43563", true, WithFileCounts())
+			So(err, ShouldBeNil)
+			So(root.FileCount, ShouldEqual, 3)
+			So(root.Get("a").FileCount, ShouldEqual, 1)
+			So(root.Get("a", "b").FileCount, ShouldEqual, 2)
+			So(root.Get("a", "f").FileCount, ShouldEqual, 0)
+			So(root.Get("a", "b", "c.txt").FileCount, ShouldEqual, 0)
+		})
+
+		Convey("Not recursive", func() {
+			root, err := s3.ListOfBucketFolder("x43563", false, WithFileCounts())
+			So(err, ShouldBeNil)
+			So(root.FileCount, ShouldEqual, 3)
+			So(root.Get("a").FileCount, ShouldEqual, 0)
+		})
+
+		Convey("Without the option", func() {
+			root, err := s3.ListOfBucketFolder("x43563", true)
+			So(err, ShouldBeNil)
+			So(root.FileCount, ShouldEqual, 0)
+			So(root.Get("a", "b").FileCount, ShouldEqual, 0)
+		})
+	})
+
+	Convey("countFiles", t, func() {
+		keys := []string{"a/b.txt", "a/b.txt", "/a//c.txt", "a/", "d.txt"}
+		root := BuildFolderTree("x43563", keys)
+		root.countFiles(keys)
+		So(root.FileCount, ShouldEqual, 1)
+		So(root.Get("a").FileCount, ShouldEqual, 2)
+	})
+
+	Convey("ListOfBucketFolderWithContext", t, func() {
+		var requests int32
+		release := make(chan struct{})
+		var releaseOnce sync.Once
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if r.URL.Query().Get("continuation-token") != "" {
+				// The second page arrives only after the listing stopped.
+				<-release
+			}
+			fmt.Fprint(w, `<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>x43563</Name><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken>`)
+			for i := 0; i < 50; i++ {
+				fmt.Fprintf(w, "<Contents><Key>a/%d.txt</Key><Size>4</Size></Contents>", i)
+			}
+			fmt.Fprint(w, "</ListBucketResult>")
+		}))
+		defer server.Close()
+		defer releaseOnce.Do(func() { close(release) })
+		s3 := newTestHelper(server)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		root, err := s3.ListOfBucketFolderWithContext(ctx, "x43563", true)
+		So(err, ShouldEqual, context.Canceled)
+		So(root, ShouldBeNil)
+		So(time.Since(start), ShouldBeLessThan, time.Second)
+
+		// The listing goroutine does not request the next page.
+		releaseOnce.Do(func() { close(release) })
+		time.Sleep(100 * time.Millisecond)
+		So(atomic.LoadInt32(&requests), ShouldEqual, 2)
+	})
+}
@@ -0,0 +1,97 @@
+package s3
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/pkg/errors"
+)
+
+// iso8601DateFormat is the date format of the signature version 4.
+const iso8601DateFormat = "20060102T150405Z"
+
+// UploadGrant lets a browser upload a file with an HTML form POST. The form
+// must post the form data and the key, Content-Type and file fields to the
+// URL.
+type UploadGrant struct {
+	URL      *url.URL
+	FormData map[string]string
+	Expires  time.Time
+}
+
+// UploadGrant returns a POST policy which allows uploading files under the
+// key prefix, with a content type starting with allowedMimePrefix, like
+// image/, and of at most maxSize bytes, until the expiry passes. An empty
+// allowedMimePrefix allows any content type.
+func (s helper) UploadGrant(bucket, keyPrefix string, maxSize int64, allowedMimePrefix string, expiry time.Duration) (*UploadGrant, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	err := validation.Validate(keyPrefix, validation.Required)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key prefix")
+	}
+	err = validation.Validate(maxSize, validation.Required, validation.Min(int64(1)))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid max size")
+	}
+	err = validation.Validate(expiry, validation.Required, validation.Min(time.Second))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid expiry")
+	}
+
+	now := s.now().UTC()
+	expires := now.Add(expiry)
+	formData := map[string]string{}
+	conditions := []interface{}{
+		map[string]string{"bucket": bucket},
+		[]interface{}{"starts-with", "$key", keyPrefix},
+		[]interface{}{"starts-with", "$Content-Type", allowedMimePrefix},
+		[]interface{}{"content-length-range", 0, maxSize},
+	}
+	if !s.Config.SignatureV2 {
+		formData["x-amz-algorithm"] = "AWS4-HMAC-SHA256"
+		formData["x-amz-credential"] = s3signer.GetCredential(s.Config.AccessKeyID, s.Config.Region, now)
+		formData["x-amz-date"] = now.Format(iso8601DateFormat)
+		for _, field := range []string{"x-amz-algorithm", "x-amz-credential", "x-amz-date"} {
+			conditions = append(conditions, map[string]string{field: formData[field]})
+		}
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": expires.Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal failed")
+	}
+
+	policyBase64 := base64.StdEncoding.EncodeToString(policy)
+	formData["policy"] = policyBase64
+	if s.Config.SignatureV2 {
+		formData["AWSAccessKeyId"] = s.Config.AccessKeyID
+		formData["signature"] = s3signer.PostPresignSignatureV2(policyBase64, s.Config.SecretAccessKey)
+	} else {
+		formData["x-amz-signature"] = s3signer.PostPresignSignatureV4(policyBase64, now, s.Config.SecretAccessKey, s.Config.Region)
+	}
+
+	scheme := "http"
+	if s.Config.SSL {
+		scheme = "https"
+	}
+
+	return &UploadGrant{
+		URL: &url.URL{
+			Scheme: scheme,
+			Host:   s.Config.Endpoint,
+			Path:   "/" + bucket + "/",
+		},
+		FormData: formData,
+		Expires:  expires,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package s3
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/pkg/s3signer"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUploadGrant(t *testing.T) {
+	Convey("UploadGrant", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.UploadGrant("x43563", "users/1/", 1<<20, "image/", time.Hour)
+			So(err, ShouldNotBeNil)
+		})
+
+		now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+		config := Config{
+			AccessKeyID:     "x",
+			Endpoint:        "localhost:9000",
+			Region:          "x",
+			SecretAccessKey: "x",
+			BucketName:      "x",
+			Clock: func() time.Time {
+				return now
+			},
+		}
+
+		decode := func(grant *UploadGrant) map[string]interface{} {
+			data, err := base64.StdEncoding.DecodeString(grant.FormData["policy"])
+			So(err, ShouldBeNil)
+			policy := map[string]interface{}{}
+			So(json.Unmarshal(data, &policy), ShouldBeNil)
+			return policy
+		}
+
+		Convey("Success", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			grant, err := s3.UploadGrant("x43563", "users/1/", 1<<20, "image/", time.Hour)
+			So(err, ShouldBeNil)
+			So(grant.URL.String(), ShouldEqual, "http://localhost:9000/x43563/")
+			So(grant.Expires, ShouldResemble, now.Add(time.Hour))
+			So(grant.FormData["x-amz-algorithm"], ShouldEqual, "AWS4-HMAC-SHA256")
+			So(grant.FormData["x-amz-credential"], ShouldEqual, "x/20180601/x/s3/aws4_request")
+			So(grant.FormData["x-amz-date"], ShouldEqual, "20180601T120000Z")
+			So(grant.FormData["x-amz-signature"], ShouldEqual, s3signer.PostPresignSignatureV4(grant.FormData["policy"], now, "x", "x"))
+
+			policy := decode(grant)
+			So(policy["expiration"], ShouldEqual, "2018-06-01T13:00:00.000Z")
+			conditions := policy["conditions"].([]interface{})
+			So(conditions, ShouldContain, map[string]interface{}{"bucket": "x43563"})
+			So(conditions, ShouldContain, []interface{}{"starts-with", "$key", "users/1/"})
+			So(conditions, ShouldContain, []interface{}{"starts-with", "$Content-Type", "image/"})
+			So(conditions, ShouldContain, []interface{}{"content-length-range", float64(0), float64(1 << 20)})
+			So(conditions, ShouldContain, map[string]interface{}{"x-amz-date": "20180601T120000Z"})
+		})
+
+		Convey("Signature V2", func() {
+			config.SignatureV2 = true
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			grant, err := s3.UploadGrant("x43563", "users/1/", 1<<20, "", time.Hour)
+			So(err, ShouldBeNil)
+			So(grant.FormData["AWSAccessKeyId"], ShouldEqual, "x")
+			So(grant.FormData["signature"], ShouldEqual, s3signer.PostPresignSignatureV2(grant.FormData["policy"], "x"))
+			So(grant.FormData, ShouldNotContainKey, "x-amz-signature")
+
+			conditions := decode(grant)["conditions"].([]interface{})
+			So(conditions, ShouldContain, []interface{}{"starts-with", "$Content-Type", ""})
+		})
+
+		Convey("Invalid", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			_, err = s3.UploadGrant("x43563", "", 1<<20, "image/", time.Hour)
+			So(err, ShouldNotBeNil)
+			_, err = s3.UploadGrant("x43563", "users/1/", 0, "image/", time.Hour)
+			So(err, ShouldNotBeNil)
+			_, err = s3.UploadGrant("x43563", "users/1/", 1<<20, "image/", 0)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package s3
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// uploadHeaderKey is the context key of the headers which are added to the
+// requests of an upload, see WithHeader.
+type uploadHeaderKey struct{}
+
+// headerTransport adds the headers of WithHeader to the requests whose context
+// carries them and signs the requests again, like ownerTransport. The chunk
+// signed uploads, which minio sends to the endpoints without SSL, can not be
+// signed again, the headers are added to them unsigned. The servers accept
+// that except for the x-amz-* headers, which fail the upload.
+type headerTransport struct {
+	transport http.RoundTripper
+	signer    signer
+}
+
+// newHeaderTransport wraps the transport to send the headers of the uploads.
+func newHeaderTransport(transport http.RoundTripper, config Config) *headerTransport {
+	return &headerTransport{
+		transport: transport,
+		signer:    newSigner(config),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header, _ := req.Context().Value(uploadHeaderKey{}).(http.Header)
+	if len(header) == 0 {
+		return t.transport.RoundTrip(req)
+	}
+
+	signed := *req
+	signed.Header = http.Header{}
+	for k, v := range req.Header {
+		signed.Header[k] = v
+	}
+	for k, v := range header {
+		signed.Header[k] = v
+	}
+
+	if req.Header.Get("X-Amz-Content-Sha256") == streamingPayload {
+		for k := range header {
+			if strings.HasPrefix(strings.ToLower(k), "x-amz-") {
+				if req.Body != nil {
+					req.Body.Close()
+				}
+				return nil, errors.Errorf("the %s header can not be signed on chunk signed uploads, use SSL", k)
+			}
+		}
+		return t.transport.RoundTrip(&signed)
+	}
+
+	return t.transport.RoundTrip(t.signer.sign(signed, req))
+}
@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jpegQuality is the quality of the JPEG images encoded again after their
+// orientation is normalized.
+const jpegQuality = 90
+
+// exifOrientation is the tag of the orientation in the EXIF data.
+const exifOrientation = 0x0112
+
+// CreateImageFile uploads the image like CreateFile. The JPEG images rotated
+// by their EXIF orientation are rotated and encoded again without the EXIF
+// data, so every browser shows them the same way. The whole JPEG image is
+// read into memory, the other content is uploaded as it is, like by
+// CreateFileStream.
+func (s helper) CreateImageFile(bucket, directory, fileName string, content io.Reader, mime string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	if !strings.EqualFold(mime, "image/jpeg") {
+		return s.CreateFile(bucket, directory, fileName, content, -1, mime)
+	}
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return errors.Wrap(err, "read failed")
+	}
+
+	orientation := jpegOrientation(data)
+	if orientation > 1 && orientation <= 8 {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return errors.Wrap(err, "jpeg.Decode failed")
+		}
+
+		buf := &bytes.Buffer{}
+		err = jpeg.Encode(buf, orientImage(img, orientation), &jpeg.Options{Quality: jpegQuality})
+		if err != nil {
+			return errors.Wrap(err, "jpeg.Encode failed")
+		}
+		data = buf.Bytes()
+	}
+
+	return s.CreateFile(bucket, directory, fileName, bytes.NewReader(data), int64(len(data)), mime)
+}
+
+// jpegOrientation returns the EXIF orientation of the JPEG image, or 1, the
+// normal orientation, if the image has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			// The image data starts, no more metadata, or the segment is
+			// malformed.
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// tiffOrientation returns the orientation tag of the first IFD of the TIFF
+// structure of the EXIF data.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientation {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+
+	return 1
+}
+
+// orientImage returns the image transformed by the EXIF orientation, so it is
+// shown as intended without the orientation.
+func orientImage(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		// The image is transposed.
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,150 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// rotatedJPEG returns a 16x8 JPEG image, red on the left and blue on the
+// right, with the EXIF orientation.
+func rotatedJPEG(orientation uint16) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 8 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	buf := &bytes.Buffer{}
+	jpeg.Encode(buf, img, &jpeg.Options{Quality: 100})
+
+	// A big endian TIFF structure with one IFD holding the orientation.
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], exifOrientation)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	exif := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	segment = append(segment, exif...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestImage(t *testing.T) {
+	Convey("CreateImageFile", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CreateImageFile("x43563", "dir", "image.jpg", bytes.NewReader(nil), "image/jpeg")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		isRed := func(c color.Color) bool {
+			r, g, b, _ := c.RGBA()
+			return r > 0xC000 && g < 0x4000 && b < 0x4000
+		}
+
+		Convey("Rotated JPEG", func() {
+			content := rotatedJPEG(6)
+			So(jpegOrientation(content), ShouldEqual, 6)
+
+			err := s3.CreateImageFile("x43563", "dir", "image.jpg", bytes.NewReader(content), "image/jpeg")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/image.jpg")
+			So(ok, ShouldBeTrue)
+			So(obj.header.Get("Content-Type"), ShouldEqual, "image/jpeg")
+			So(jpegOrientation(obj.data), ShouldEqual, 1)
+
+			// Rotated clockwise the left half is on the top.
+			img, err := jpeg.Decode(bytes.NewReader(obj.data))
+			So(err, ShouldBeNil)
+			So(img.Bounds().Dx(), ShouldEqual, 8)
+			So(img.Bounds().Dy(), ShouldEqual, 16)
+			So(isRed(img.At(4, 3)), ShouldBeTrue)
+			So(isRed(img.At(4, 12)), ShouldBeFalse)
+		})
+
+		Convey("Normal JPEG", func() {
+			content := rotatedJPEG(1)
+			err := s3.CreateImageFile("x43563", "dir", "image.jpg", bytes.NewReader(content), "image/jpeg")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/image.jpg")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+		})
+
+		Convey("Other content", func() {
+			err := s3.CreateImageFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), "text/plain")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+		})
+
+		Convey("Invalid JPEG", func() {
+			content := rotatedJPEG(6)
+			err := s3.CreateImageFile("x43563", "dir", "image.jpg", bytes.NewReader(content[:200]), "image/jpeg")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Malformed header", func() {
+			content := []byte("\xFF\xD8\xFF\xE1\x00\x00Exif\x00\x00")
+			err := s3.CreateImageFile("x43563", "dir", "image.jpg", bytes.NewReader(content), "image/jpeg")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/image.jpg")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+		})
+	})
+
+	Convey("jpegOrientation", t, func() {
+		So(jpegOrientation([]byte("\xFF\xD8\xFF\xE1\x00\x00Exif\x00\x00")), ShouldEqual, 1)
+		So(jpegOrientation([]byte("\xFF\xD8\xFF\xE1\x00\x01")), ShouldEqual, 1)
+		So(jpegOrientation([]byte("\xFF\xD8\xFF\xE1\x00\x10")), ShouldEqual, 1)
+		So(jpegOrientation([]byte("asdf")), ShouldEqual, 1)
+	})
+
+	Convey("orientImage", t, func() {
+		img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+		img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+		for orientation, corner := range map[int]image.Point{
+			1: {0, 0}, 2: {2, 0}, 3: {2, 1}, 4: {0, 1},
+			5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2},
+		} {
+			oriented := orientImage(img, orientation)
+			if orientation >= 5 {
+				So(oriented.Bounds().Size(), ShouldResemble, image.Pt(2, 3))
+			} else {
+				So(oriented.Bounds().Size(), ShouldResemble, image.Pt(3, 2))
+			}
+			So(oriented.At(corner.X, corner.Y), ShouldResemble, color.RGBA{R: 255, A: 255})
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package s3
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// keyTemplateRegexp checks that the key template contains the file name.
+var keyTemplateRegexp = regexp.MustCompile(`\{file\}`)
+
+// expandKeyTemplate expands the {year}, {month}, {day}, {dir} and {file}
+// placeholders of the template.
+func expandKeyTemplate(template, directory, fileName string, t time.Time) string {
+	key := strings.NewReplacer(
+		"{year}", fmt.Sprintf("%04d", t.Year()),
+		"{month}", fmt.Sprintf("%02d", t.Month()),
+		"{day}", fmt.Sprintf("%02d", t.Day()),
+		"{dir}", directory,
+		"{file}", fileName,
+	).Replace(template)
+
+	return strings.TrimPrefix(path.Clean(key), "/")
+}
+
+// ResolveKey returns the key of the file uploaded by CreateFile, or any other
+// upload method, at the given time. Without a key template the key is
+// directory/fileName.
+func (s helper) ResolveKey(directory, fileName string, uploaded time.Time) string {
+	if s.Config.KeyTemplate == "" {
+		return directory + "/" + fileName
+	}
+
+	return expandKeyTemplate(s.Config.KeyTemplate, directory, fileName, uploaded.UTC())
+}
+
+// uploadKey returns the key of the file uploaded now. The upload methods
+// resolve the key once and use it for every request of the upload.
+func (s helper) uploadKey(directory, fileName string) string {
+	return s.ResolveKey(directory, fileName, s.now())
+}
@@ -0,0 +1,129 @@
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeyTemplate(t *testing.T) {
+	Convey("expandKeyTemplate", t, func() {
+		date := time.Date(2019, 3, 7, 10, 0, 0, 0, time.UTC)
+
+		So(expandKeyTemplate("{year}/{month}/{dir}/{file}", "images", "a.jpg", date), ShouldEqual, "2019/03/images/a.jpg")
+		So(expandKeyTemplate("{dir}/{year}-{month}-{day}/{file}", "images", "a.jpg", date), ShouldEqual, "images/2019-03-07/a.jpg")
+		So(expandKeyTemplate("{year}/{dir}/{file}", "", "a.jpg", date), ShouldEqual, "2019/a.jpg")
+		So(expandKeyTemplate("/{dir}/{file}", "images/", "a.jpg", date), ShouldEqual, "images/a.jpg")
+	})
+
+	Convey("KeyTemplate", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		config := testConfig(server.Server)
+
+		Convey("Invalid template", func() {
+			config.KeyTemplate = "{year}/{dir}"
+			_, err := New(config)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("No template", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			So(s3.ResolveKey("images", "a.jpg", time.Now()), ShouldEqual, "images/a.jpg")
+		})
+
+		Convey("Round trip", func() {
+			config.KeyTemplate = "{year}/{month}/{dir}/{file}"
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			uploaded := time.Now()
+			err = s3.CreateFile("x43563", "images", "a.jpg", bytes.NewReader([]byte("asdf")), 4, "image/jpeg")
+			So(err, ShouldBeNil)
+
+			key := s3.ResolveKey("images", "a.jpg", uploaded)
+			So(key, ShouldEqual, uploaded.UTC().Format("2006/01")+"/images/a.jpg")
+			So(server.keys("x43563"), ShouldResemble, []string{key})
+
+			obj, err := s3.GetFile("x43563", "", key)
+			So(err, ShouldBeNil)
+			So(obj, ShouldNotBeNil)
+			data, err := ioutil.ReadAll(obj)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "asdf")
+		})
+
+		Convey("Clock", func() {
+			config.KeyTemplate = "{year}/{month}/{day}/{dir}/{file}"
+			config.Clock = func() time.Time {
+				return time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC)
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.CreateFile("x43563", "images", "a.jpg", bytes.NewReader([]byte("asdf")), 4, "image/jpeg")
+			So(err, ShouldBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"2018/12/31/images/a.jpg"})
+		})
+
+		Convey("Upload methods", func() {
+			config.KeyTemplate = "{year}/{dir}/{file}"
+			config.Clock = func() time.Time {
+				return time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC)
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.CreateFileAtomic("x43563", "images", "b.jpg", bytes.NewReader([]byte("asdf")), 4, "image/jpeg")
+			So(err, ShouldBeNil)
+
+			err = s3.CreateFileWithCacheControl("x43563", "images", "c.jpg", bytes.NewReader([]byte("asdf")), 4, "image/jpeg", "no-cache")
+			So(err, ShouldBeNil)
+
+			So(server.keys("x43563"), ShouldResemble, []string{"2018/images/b.jpg", "2018/images/c.jpg"})
+		})
+
+		Convey("Existence checks", func() {
+			config.KeyTemplate = "{year}/{dir}/{file}"
+			day := time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC)
+			config.Clock = func() time.Time {
+				return day
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			created, err := s3.CreateFileIfNotExists("x43563", "images", "a.jpg", bytes.NewReader([]byte("asdf")), 4, "image/jpeg")
+			So(err, ShouldBeNil)
+			So(created, ShouldBeTrue)
+
+			// The file is found on the next day too.
+			day = day.Add(24 * time.Hour)
+			created, err = s3.CreateFileIfNotExists("x43563", "images", "a.jpg", bytes.NewReader([]byte("qwer")), 4, "image/jpeg")
+			So(err, ShouldBeNil)
+			So(created, ShouldBeFalse)
+
+			info, created, err := s3.GetOrCreateFile("x43563", "images", "a.jpg", bytes.NewReader([]byte("qwerty")), 6, "image/jpeg")
+			So(err, ShouldBeNil)
+			So(created, ShouldBeFalse)
+			So(info.Key, ShouldEqual, "images/a.jpg")
+			So(info.Size, ShouldEqual, 4)
+
+			key, existed, err := s3.CreateFileDedup("x43563", "images", bytes.NewReader([]byte("asdf")), "image/jpeg")
+			So(err, ShouldBeNil)
+			So(existed, ShouldBeFalse)
+			day = day.Add(24 * time.Hour)
+			again, existed, err := s3.CreateFileDedup("x43563", "images", bytes.NewReader([]byte("asdf")), "image/jpeg")
+			So(err, ShouldBeNil)
+			So(existed, ShouldBeTrue)
+			So(again, ShouldEqual, key)
+
+			found, err := s3.FileExists("x43563", "images", "a.jpg")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+		})
+	})
+}
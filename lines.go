@@ -0,0 +1,35 @@
+package s3
+
+import (
+	"bufio"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// maxLineSize is the length of the longest line GetFileLines scans.
+const maxLineSize = 1 << 20
+
+// GetFileLines returns a scanner over the lines of the file and a function
+// which closes the file. The file is streamed, it is not loaded into memory.
+// The lines may be up to 1MiB long, the scanner stops at a longer one with
+// bufio.ErrTooLong. The caller must call the close function when done with the
+// scanner.
+func (s helper) GetFileLines(bucket, directory, filename string) (*bufio.Scanner, func() error, error) {
+	if !s.Enabled {
+		return nil, nil, ErrServerDisabled
+	}
+
+	obj, _, found, err := s.openObject(bucket, filepath.Join(directory, filename), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, errors.New("file not found")
+	}
+
+	scanner := bufio.NewScanner(obj)
+	scanner.Buffer(nil, maxLineSize)
+	return scanner, obj.Close, nil
+}
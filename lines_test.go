@@ -0,0 +1,61 @@
+package s3
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLines(t *testing.T) {
+	Convey("GetFileLines", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetFileLines("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.csv", []byte("id,name\n1,first\r\n2,second\n\n3,third"), nil)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Missing file", func() {
+			_, _, err := s3.GetFileLines("x43563", "dir", "missing.csv")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Scan lines", func() {
+			scanner, closeFile, err := s3.GetFileLines("x43563", "dir", "file.csv")
+			So(err, ShouldBeNil)
+
+			lines := []string{}
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			So(scanner.Err(), ShouldBeNil)
+			So(closeFile(), ShouldBeNil)
+			So(lines, ShouldResemble, []string{"id,name", "1,first", "2,second", "", "3,third"})
+		})
+
+		Convey("Long lines", func() {
+			long := strings.Repeat("x", 100<<10)
+			server.put("x43563", "dir/long.txt", []byte(long+"\nshort\n"+strings.Repeat("y", maxLineSize+1)+"\n"), nil)
+
+			scanner, closeFile, err := s3.GetFileLines("x43563", "dir", "long.txt")
+			So(err, ShouldBeNil)
+			defer closeFile()
+
+			lines := []string{}
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			So(lines, ShouldResemble, []string{long, "short"})
+			So(scanner.Err(), ShouldEqual, bufio.ErrTooLong)
+		})
+	})
+}
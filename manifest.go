@@ -0,0 +1,90 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"strings"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// manifestName is the name of the manifest stored by WriteManifest.
+const manifestName = "manifest.json"
+
+// ManifestEntry describes a file of the manifest.
+type ManifestEntry struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"lastModified"`
+	ContentType  string    `json:"contentType"`
+}
+
+// GenerateManifest lists the files under the prefix recursively and returns
+// them as a JSON array of ManifestEntry, sorted by key. The directory markers
+// and the manifest of the prefix are left out. The listing has no content
+// types, so every file is statted.
+func (s helper) GenerateManifest(bucket, prefix string) ([]byte, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	manifestKey := path.Join(prefix, manifestName)
+	refs := []KeyRef{}
+	for obj := range s.Client.ListObjectsV2(bucket, prefix, true, doneCh) {
+		if obj.Err != nil {
+			return nil, errors.Wrap(obj.Err, "list object error")
+		}
+		if strings.HasSuffix(obj.Key, "/") || path.Base(obj.Key) == directoryMarker || obj.Key == manifestKey {
+			continue
+		}
+		refs = append(refs, KeyRef{Directory: path.Dir(obj.Key), FileName: path.Base(obj.Key)})
+	}
+
+	infos, err := s.StatFiles(bucket, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []ManifestEntry{}
+	for _, ref := range refs {
+		info, ok := infos[ref.Key()]
+		if !ok {
+			// Removed since the listing.
+			continue
+		}
+		entries = append(entries, ManifestEntry{
+			Key:          ref.Key(),
+			Size:         info.Size,
+			ETag:         info.ETag,
+			LastModified: info.LastModified,
+			ContentType:  info.ContentType,
+		})
+	}
+
+	manifest, err := json.Marshal(entries)
+	if err != nil {
+		return nil, errors.Wrap(err, "json.Marshal failed")
+	}
+	return manifest, nil
+}
+
+// WriteManifest generates the manifest of the prefix and stores it as
+// manifest.json under the prefix.
+func (s helper) WriteManifest(bucket, prefix string) error {
+	manifest, err := s.GenerateManifest(bucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType: "application/json",
+	}
+	return s.putObject(bucket, path.Join(prefix, manifestName), bytes.NewReader(manifest), int64(len(manifest)), opts)
+}
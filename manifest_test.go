@@ -0,0 +1,81 @@
+package s3
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManifest(t *testing.T) {
+	Convey("GenerateManifest", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.GenerateManifest("x43563", "dir/")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/a.txt", []byte("asdf"), http.Header{"Content-Type": {"text/plain"}})
+		server.put("x43563", "dir/sub/b.png", []byte("png!!"), http.Header{"Content-Type": {"image/png"}})
+		server.put("x43563", "dir/sub/"+directoryMarker, []byte("marker"), nil)
+		server.put("x43563", "other/c.txt", []byte("asdf"), nil)
+		modified := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+		for _, key := range []string{"dir/a.txt", "dir/sub/b.png"} {
+			obj, _ := server.get("x43563", key)
+			obj.lastModified = modified
+		}
+		a, _ := server.get("x43563", "dir/a.txt")
+		b, _ := server.get("x43563", "dir/sub/b.png")
+		s3 := newTestHelper(server.Server)
+
+		expected := []ManifestEntry{
+			{Key: "dir/a.txt", Size: 4, ETag: a.etag, LastModified: modified, ContentType: "text/plain"},
+			{Key: "dir/sub/b.png", Size: 5, ETag: b.etag, LastModified: modified, ContentType: "image/png"},
+		}
+
+		Convey("Success", func() {
+			manifest, err := s3.GenerateManifest("x43563", "dir/")
+			So(err, ShouldBeNil)
+
+			entries := []ManifestEntry{}
+			So(json.Unmarshal(manifest, &entries), ShouldBeNil)
+			So(entries, ShouldResemble, expected)
+
+			raw := []map[string]interface{}{}
+			So(json.Unmarshal(manifest, &raw), ShouldBeNil)
+			So(raw[0], ShouldContainKey, "lastModified")
+			So(raw[0], ShouldContainKey, "contentType")
+		})
+
+		Convey("Empty prefix", func() {
+			manifest, err := s3.GenerateManifest("x43563", "missing/")
+			So(err, ShouldBeNil)
+			So(string(manifest), ShouldEqual, "[]")
+		})
+
+		Convey("WriteManifest", func() {
+			err := s3.WriteManifest("x43563", "dir/")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/manifest.json")
+			So(ok, ShouldBeTrue)
+			So(obj.header.Get("Content-Type"), ShouldEqual, "application/json")
+
+			entries := []ManifestEntry{}
+			So(json.Unmarshal(obj.data, &entries), ShouldBeNil)
+			So(entries, ShouldResemble, expected)
+
+			// The stored manifest is not listed in the next one.
+			manifest, err := s3.GenerateManifest("x43563", "dir/")
+			So(err, ShouldBeNil)
+			So(manifest, ShouldResemble, obj.data)
+		})
+	})
+}
@@ -0,0 +1,71 @@
+package s3
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// directoryMarker is the name of the marker object created by CreateDirectory.
+const directoryMarker = ".created"
+
+// defaultDirectoryMarkerContentType is the content type of the directory
+// markers if the config does not set one.
+const defaultDirectoryMarkerContentType = "text/plain"
+
+// directoryMarkerContentType returns the content type of the directory markers.
+func (s helper) directoryMarkerContentType() string {
+	if s.Config.DirectoryMarkerContentType != "" {
+		return s.Config.DirectoryMarkerContentType
+	}
+	return defaultDirectoryMarkerContentType
+}
+
+// EnsureDirectoryMarkers creates the missing directory markers of the logical
+// directories under the prefix, like CreateDirectory does, and returns the
+// number of the created markers.
+func (s helper) EnsureDirectoryMarkers(bucket, prefix string) (int, error) {
+	if !s.Enabled {
+		return 0, ErrServerDisabled
+	}
+
+	objects, err := s.listPrefix(bucket, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	base := strings.Trim(prefix, "/")
+	inPrefix := func(dir string) bool {
+		return base == "" || dir == base || strings.HasPrefix(dir, base+"/")
+	}
+
+	directories := map[string]bool{}
+	markers := map[string]bool{}
+	for _, obj := range objects {
+		if path.Base(obj.Key) == directoryMarker {
+			markers[path.Dir(obj.Key)] = true
+		}
+		for dir := path.Dir(obj.Key); dir != "." && dir != "/" && inPrefix(dir); dir = path.Dir(dir) {
+			directories[dir] = true
+		}
+	}
+
+	missing := []string{}
+	for dir := range directories {
+		if !markers[dir] {
+			missing = append(missing, dir)
+		}
+	}
+	sort.Strings(missing)
+
+	for i, dir := range missing {
+		err = s.CreateDirectory(bucket, dir)
+		if err != nil {
+			return i, errors.Wrap(err, "CreateDirectory failed")
+		}
+	}
+
+	return len(missing), nil
+}
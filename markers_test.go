@@ -0,0 +1,94 @@
+package s3
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMarkers(t *testing.T) {
+	Convey("EnsureDirectoryMarkers", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.EnsureDirectoryMarkers("x43563", "site")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "site/.created", []byte("marker"), nil)
+		server.put("x43563", "site/index.html", []byte("index"), nil)
+		server.put("x43563", "site/css/style.css", []byte("style"), nil)
+		server.put("x43563", "site/js/.created", []byte("marker"), nil)
+		server.put("x43563", "site/js/app/main.js", []byte("main"), nil)
+		server.put("x43563", "other/file.txt", []byte("other"), nil)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Missing markers", func() {
+			added, err := s3.EnsureDirectoryMarkers("x43563", "site/")
+			So(err, ShouldBeNil)
+			So(added, ShouldEqual, 2)
+			So(server.keys("x43563"), ShouldResemble, []string{
+				"other/file.txt",
+				"site/.created",
+				"site/css/.created",
+				"site/css/style.css",
+				"site/index.html",
+				"site/js/.created",
+				"site/js/app/.created",
+				"site/js/app/main.js",
+			})
+
+			added, err = s3.EnsureDirectoryMarkers("x43563", "site/")
+			So(err, ShouldBeNil)
+			So(added, ShouldEqual, 0)
+		})
+
+		Convey("Whole bucket", func() {
+			added, err := s3.EnsureDirectoryMarkers("x43563", "")
+			So(err, ShouldBeNil)
+			So(added, ShouldEqual, 3)
+
+			_, ok := server.get("x43563", "other/.created")
+			So(ok, ShouldBeTrue)
+		})
+	})
+
+	Convey("CreateDirectory", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+
+		created := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+		config := testConfig(server.Server)
+		config.Clock = func() time.Time {
+			return created
+		}
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		err = s3.CreateDirectory("x43563", "dir")
+		So(err, ShouldBeNil)
+
+		obj, ok := server.get("x43563", "dir/"+directoryMarker)
+		So(ok, ShouldBeTrue)
+		So(string(obj.data), ShouldEqual, created.String())
+		So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+
+		Convey("Configured content type", func() {
+			config.DirectoryMarkerContentType = "application/x-directory"
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.CreateDirectory("x43563", "other")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "other/"+directoryMarker)
+			So(ok, ShouldBeTrue)
+			So(obj.header.Get("Content-Type"), ShouldEqual, "application/x-directory")
+		})
+	})
+}
@@ -0,0 +1,331 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/encrypt"
+	"github.com/pkg/errors"
+)
+
+// Part limits of the multipart uploads.
+const (
+	// defaultUploadPartSize is the part size of the resumable uploads.
+	defaultUploadPartSize = 16 << 20
+	// streamUploadPartSize is the part size of the uploads with unknown
+	// length, the one minio uses: the smallest multiple of 64MiB which fits
+	// the largest object into the maximum number of parts.
+	streamUploadPartSize = 576 << 20
+	minUploadPartSize    = 5 << 20
+	maxUploadParts       = 10000
+	maxObjectSize        = 5 << 40
+)
+
+// scalePartSize returns the part size of an upload of the given length: the
+// given part size, or larger if the upload would not fit into the maximum
+// number of parts.
+func scalePartSize(partSize, length int64) int64 {
+	if length > partSize*maxUploadParts {
+		return (length + maxUploadParts - 1) / maxUploadParts
+	}
+	return partSize
+}
+
+// readPart reads the next part of at most size bytes into buf. The buffer is
+// grown as the part is read, so a short upload does not allocate a whole
+// part. The error is io.EOF if the content ended.
+func readPart(r io.Reader, buf []byte, size int) ([]byte, error) {
+	buf = buf[:0]
+	for len(buf) < size {
+		if len(buf) == cap(buf) {
+			grown := 2*cap(buf) + bytes.MinRead
+			if grown > size {
+				grown = size
+			}
+			buf = append(make([]byte, 0, grown), buf...)
+		}
+
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// putObjectPart uploads a part of the multipart upload and returns its ETag.
+// The part is sent with the context, unlike by the minio client, so the
+// request in progress stops when the context is cancelled.
+func (s helper) putObjectPart(ctx context.Context, bucket, key, uploadID string, partNumber int, data []byte, sse encrypt.ServerSide) (string, error) {
+	header := http.Header{}
+	if sse != nil && sse.Type() == encrypt.SSEC {
+		sse.Marshal(header)
+	}
+
+	resp, err := s.executeMethod(ctx, "PUT", requestMetadata{
+		bucketName: bucket,
+		objectName: key,
+		queryValues: url.Values{
+			"partNumber": {strconv.Itoa(partNumber)},
+			"uploadId":   {uploadID},
+		},
+		header:  header,
+		content: data,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+// newMultipartUpload initiates a multipart upload of the object with the
+// headers and returns its upload ID.
+func (s helper) newMultipartUpload(ctx context.Context, bucket, key string, header http.Header) (string, error) {
+	resp, err := s.executeMethod(ctx, "POST", requestMetadata{
+		bucketName:  bucket,
+		objectName:  key,
+		queryValues: url.Values{"uploads": {""}},
+		header:      header,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	initiated := struct {
+		UploadID string `xml:"UploadId"`
+	}{}
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	if err != nil {
+		return "", errors.Wrap(err, "xml.Decode failed")
+	}
+	return initiated.UploadID, nil
+}
+
+// completeMultipartUpload completes the multipart upload with the parts. The
+// server may report the error with a 200 response, as it starts the response
+// before the parts are assembled.
+func (s helper) completeMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []minio.CompletePart) error {
+	content, err := xml.Marshal(struct {
+		XMLName xml.Name             `xml:"CompleteMultipartUpload"`
+		Parts   []minio.CompletePart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return errors.Wrap(err, "xml.Marshal failed")
+	}
+
+	resp, err := s.executeMethod(ctx, "POST", requestMetadata{
+		bucketName:  bucket,
+		objectName:  key,
+		queryValues: url.Values{"uploadId": {uploadID}},
+		content:     content,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	result := struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}{}
+	err = xml.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return errors.Wrap(err, "xml.Decode failed")
+	}
+	if result.XMLName.Local == "Error" {
+		return minio.ErrorResponse{
+			Code:       result.Code,
+			Message:    result.Message,
+			BucketName: bucket,
+			Key:        key,
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return nil
+}
+
+// abortMultipartUpload aborts the multipart upload. It is sent even if the
+// context is cancelled, with the headers of the upload.
+func (s helper) abortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	ctx = context.WithValue(context.Background(), uploadHeaderKey{}, ctx.Value(uploadHeaderKey{}))
+	resp, err := s.executeMethod(ctx, "DELETE", requestMetadata{
+		bucketName:  bucket,
+		objectName:  key,
+		queryValues: url.Values{"uploadId": {uploadID}},
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// putObjectMultipart uploads the content of unknown length in parts of the
+// given size. A part is buffered in memory at a time, the content which ends
+// within the first part is sent in a single request. The upload is aborted if
+// any of the parts fails or the context is cancelled, the error is returned
+// as AbortedUploadError.
+func (s helper) putObjectMultipart(ctx context.Context, bucket, key string, content io.Reader, partSize int64, opts minio.PutObjectOptions) error {
+	buf, readErr := readPart(content, nil, int(partSize))
+	if readErr != nil && readErr != io.EOF {
+		return errors.Wrap(readErr, "read failed")
+	}
+	if readErr == io.EOF {
+		_, err := s.Client.PutObjectWithContext(ctx, bucket, key, bytes.NewReader(buf), int64(len(buf)), opts)
+		return err
+	}
+
+	uploadID, err := s.newMultipartUpload(ctx, bucket, key, opts.Header())
+	if err != nil {
+		return errors.Wrap(err, "NewMultipartUpload failed")
+	}
+
+	abort := func(err error) error {
+		return &AbortedUploadError{
+			Err:      err,
+			UploadID: uploadID,
+			AbortErr: s.abortMultipartUpload(ctx, bucket, key, uploadID),
+		}
+	}
+
+	parts := []minio.CompletePart{}
+	for partNumber := 1; ; partNumber++ {
+		if err := ctx.Err(); err != nil {
+			return abort(err)
+		}
+		if partNumber > maxUploadParts {
+			return abort(errors.New("too many parts"))
+		}
+
+		if partNumber > 1 {
+			buf, readErr = readPart(content, buf, int(partSize))
+			if readErr == io.EOF && len(buf) == 0 {
+				break
+			}
+			if readErr != nil && readErr != io.EOF {
+				return abort(errors.Wrap(readErr, "read failed"))
+			}
+		}
+
+		etag, err := s.putObjectPart(ctx, bucket, key, uploadID, partNumber, buf, opts.ServerSideEncryption)
+		if ctx.Err() != nil {
+			return abort(ctx.Err())
+		}
+		if err != nil {
+			return abort(errors.Wrap(err, "PutObjectPart failed"))
+		}
+		parts = append(parts, minio.CompletePart{PartNumber: partNumber, ETag: etag})
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return abort(err)
+	}
+
+	err = s.completeMultipartUpload(ctx, bucket, key, uploadID, parts)
+	if err != nil {
+		return abort(errors.Wrap(err, "CompleteMultipartUpload failed"))
+	}
+
+	return nil
+}
+
+// etagParts returns the number of parts from the ETag of a multipart upload,
+// which is the hash of the part hashes followed by -N. The ETags of the
+// objects uploaded in one part have no suffix and count as one part.
+func etagParts(etag string) (int, error) {
+	etag = strings.Trim(etag, `"`)
+	i := strings.LastIndex(etag, "-")
+	if i < 0 {
+		return 1, nil
+	}
+
+	parts, err := strconv.Atoi(etag[i+1:])
+	if err != nil || parts < 1 {
+		return 0, errors.Errorf("invalid ETag: %s", etag)
+	}
+	return parts, nil
+}
+
+// GetObjectParts returns the number of parts the file was uploaded with, based
+// on its ETag. Copied and encrypted objects may have ETags which do not follow
+// the format.
+func (s helper) GetObjectParts(bucket, directory, filename string) (int, error) {
+	if !s.Enabled {
+		return 0, ErrServerDisabled
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, errors.New("file not found")
+	}
+
+	return etagParts(info.ETag)
+}
+
+// ListIncompleteUploads lists the incomplete multipart uploads under the
+// prefix, with the size of their uploaded parts.
+func (s helper) ListIncompleteUploads(bucket, prefix string) ([]minio.ObjectMultipartInfo, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	uploads := []minio.ObjectMultipartInfo{}
+	for upload := range s.Client.ListIncompleteUploads(bucket, prefix, true, doneCh) {
+		if upload.Err != nil {
+			return nil, errors.Wrap(upload.Err, "list incomplete uploads error")
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, nil
+}
+
+// AbortIncompleteUploads aborts the incomplete multipart uploads under the
+// prefix which were initiated more than olderThan ago, and returns the number
+// of the aborted uploads.
+func (s helper) AbortIncompleteUploads(bucket, prefix string, olderThan time.Duration) (int, error) {
+	uploads, err := s.ListIncompleteUploads(bucket, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	core := minio.Core{Client: s.Client}
+	deadline := s.now().Add(-olderThan)
+	aborted := 0
+	for _, upload := range uploads {
+		if upload.Initiated.After(deadline) {
+			continue
+		}
+
+		err := core.AbortMultipartUpload(bucket, upload.Key, upload.UploadID)
+		if err != nil {
+			return aborted, errors.Wrap(err, "AbortMultipartUpload failed")
+		}
+		aborted++
+	}
+
+	return aborted, nil
+}
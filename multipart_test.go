@@ -0,0 +1,326 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// cancelReader cancels the context once the given number of bytes were read.
+type cancelReader struct {
+	io.Reader
+	cancel func()
+	after  int64
+	read   int64
+}
+
+// Read implements io.Reader.
+func (r *cancelReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+	if r.read >= r.after {
+		r.cancel()
+	}
+	return n, err
+}
+
+func TestMultipart(t *testing.T) {
+	Convey("UploadPartSize", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		config := testConfig(server.Server)
+
+		partUploads := func() int {
+			n := 0
+			for _, r := range server.received() {
+				if r.Method == "PUT" && r.Query.Get("uploadId") != "" {
+					n++
+				}
+			}
+			return n
+		}
+
+		Convey("Too small", func() {
+			config.UploadPartSize = 1 << 20
+			_, err := New(config)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Large file", func() {
+			config.UploadPartSize = 5 << 20
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			content := bytes.Repeat([]byte("0123456789abcdef"), 12<<16)
+			err = s3.CreateFile("x43563", "dir", "file.bin", bytes.NewReader(content), int64(len(content)), "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(partUploads(), ShouldEqual, 3)
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+			So(obj.etag, ShouldEndWith, "-3")
+		})
+
+		Convey("Small file", func() {
+			config.UploadPartSize = 5 << 20
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.CreateFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(partUploads(), ShouldEqual, 0)
+			So(server.keys("x43563"), ShouldResemble, []string{"dir/file.txt"})
+		})
+
+		Convey("Unknown length", func() {
+			config.UploadPartSize = 5 << 20
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			content := bytes.Repeat([]byte("0123456789abcdef"), 6<<16)
+			err = s3.CreateFile("x43563", "dir", "file.bin", bytes.NewReader(content), -1, "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(partUploads(), ShouldEqual, 2)
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+		})
+
+		Convey("Small file of unknown length", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.CreateFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), -1, "text/plain")
+			So(err, ShouldBeNil)
+			So(server.received(), ShouldHaveLength, 1)
+			So(server.uploadIDs(), ShouldBeEmpty)
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+		})
+	})
+
+	Convey("scalePartSize", t, func() {
+		So(scalePartSize(minUploadPartSize, -1), ShouldEqual, minUploadPartSize)
+		So(scalePartSize(minUploadPartSize, 1<<30), ShouldEqual, minUploadPartSize)
+		So(scalePartSize(minUploadPartSize, minUploadPartSize*maxUploadParts), ShouldEqual, minUploadPartSize)
+		So(scalePartSize(minUploadPartSize, minUploadPartSize*maxUploadParts+1), ShouldEqual, minUploadPartSize+1)
+		So(scalePartSize(minUploadPartSize, 5<<40), ShouldEqual, (5<<40+maxUploadParts-1)/maxUploadParts)
+	})
+
+	Convey("streamUploadPartSize", t, func() {
+		So(streamUploadPartSize*maxUploadParts, ShouldBeGreaterThanOrEqualTo, maxObjectSize)
+		So(streamUploadPartSize%(64<<20), ShouldEqual, 0)
+	})
+
+	Convey("readPart", t, func() {
+		content := bytes.NewReader(bytes.Repeat([]byte("x"), 5000))
+
+		buf, err := readPart(content, nil, 3000)
+		So(err, ShouldBeNil)
+		So(buf, ShouldHaveLength, 3000)
+
+		buf, err = readPart(content, buf, 3000)
+		So(err, ShouldEqual, io.EOF)
+		So(buf, ShouldHaveLength, 2000)
+
+		buf, err = readPart(content, buf, 3000)
+		So(err, ShouldEqual, io.EOF)
+		So(buf, ShouldBeEmpty)
+	})
+
+	Convey("CreateFileWithContext", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		config := testConfig(server.Server)
+		config.UploadPartSize = 5 << 20
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Success", func() {
+			content := bytes.Repeat([]byte("0123456789abcdef"), 6<<16)
+			err := s3.CreateFileWithContext(context.Background(), "x43563", "dir", "file.bin", bytes.NewReader(content), -1, "application/octet-stream")
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+		})
+
+		Convey("Cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			content := &cancelReader{
+				Reader: bytes.NewReader(bytes.Repeat([]byte("0123456789abcdef"), 12<<16)),
+				cancel: cancel,
+				after:  6 << 20,
+			}
+
+			err := s3.CreateFileWithContext(ctx, "x43563", "dir", "file.bin", content, -1, "application/octet-stream")
+			So(err, ShouldNotBeNil)
+			So(errors.Cause(err), ShouldEqual, context.Canceled)
+
+			aborted, ok := err.(*AbortedUploadError)
+			So(ok, ShouldBeTrue)
+			So(aborted.AbortErr, ShouldBeNil)
+
+			aborts := 0
+			for _, r := range server.received() {
+				if r.Method == "DELETE" && r.Query.Get("uploadId") == aborted.UploadID {
+					aborts++
+				}
+			}
+			So(aborts, ShouldEqual, 1)
+			So(server.uploadIDs(), ShouldBeEmpty)
+			So(server.keys("x43563"), ShouldBeEmpty)
+		})
+
+		Convey("Cancelled during a part", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The part upload blocks until the request is cancelled. The body
+			// is read first, the server notices the closed connection after.
+			blocking := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "PUT" && r.URL.Query().Get("partNumber") != "" {
+					io.Copy(ioutil.Discard, r.Body)
+					cancel()
+					select {
+					case <-r.Context().Done():
+					case <-time.After(5 * time.Second):
+					}
+					return
+				}
+				server.serveHTTP(w, r)
+			}))
+			defer blocking.Close()
+			config := testConfig(blocking)
+			config.UploadPartSize = 5 << 20
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			start := time.Now()
+			content := bytes.Repeat([]byte("0123456789abcdef"), 6<<16)
+			err = s3.CreateFileWithContext(ctx, "x43563", "dir", "file.bin", bytes.NewReader(content), -1, "application/octet-stream")
+			So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+			So(errors.Cause(err), ShouldEqual, context.Canceled)
+
+			aborted, ok := err.(*AbortedUploadError)
+			So(ok, ShouldBeTrue)
+			So(aborted.AbortErr, ShouldBeNil)
+			So(server.uploadIDs(), ShouldBeEmpty)
+			So(server.keys("x43563"), ShouldBeEmpty)
+		})
+	})
+
+	Convey("GetObjectParts", t, func() {
+		Convey("ETag", func() {
+			parts, err := etagParts("abc-5")
+			So(err, ShouldBeNil)
+			So(parts, ShouldEqual, 5)
+
+			parts, err = etagParts(`"abc-12"`)
+			So(err, ShouldBeNil)
+			So(parts, ShouldEqual, 12)
+
+			parts, err = etagParts("abc")
+			So(err, ShouldBeNil)
+			So(parts, ShouldEqual, 1)
+
+			_, err = etagParts("abc-x")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		config := testConfig(server.Server)
+		config.UploadPartSize = 5 << 20
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Multipart", func() {
+			content := bytes.Repeat([]byte("0123456789abcdef"), 12<<16)
+			err := s3.CreateFile("x43563", "dir", "file.bin", bytes.NewReader(content), int64(len(content)), "application/octet-stream")
+			So(err, ShouldBeNil)
+
+			parts, err := s3.GetObjectParts("x43563", "dir", "file.bin")
+			So(err, ShouldBeNil)
+			So(parts, ShouldEqual, 3)
+		})
+
+		Convey("Single part", func() {
+			err := s3.CreateFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+
+			parts, err := s3.GetObjectParts("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(parts, ShouldEqual, 1)
+		})
+
+		Convey("Missing file", func() {
+			_, err := s3.GetObjectParts("x43563", "dir", "missing.txt")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("ListIncompleteUploads", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.ListIncompleteUploads("x43563", "dir/")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := newFakeS3()
+			defer server.Close()
+			server.startUpload("x43563", "dir/a.bin", time.Now(), []byte("abc"), []byte("de"))
+			server.startUpload("x43563", "other/b.bin", time.Now())
+
+			s3 := newTestHelper(server.Server)
+			uploads, err := s3.ListIncompleteUploads("x43563", "dir/")
+			So(err, ShouldBeNil)
+			So(uploads, ShouldHaveLength, 1)
+			So(uploads[0].Key, ShouldEqual, "dir/a.bin")
+			So(uploads[0].Size, ShouldEqual, 5)
+		})
+	})
+
+	Convey("AbortIncompleteUploads", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.AbortIncompleteUploads("x43563", "dir/", time.Hour)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := newFakeS3()
+			defer server.Close()
+			server.startUpload("x43563", "dir/old.bin", time.Now().Add(-48*time.Hour), []byte("abc"))
+			recent := server.startUpload("x43563", "dir/recent.bin", time.Now())
+			other := server.startUpload("x43563", "other/old.bin", time.Now().Add(-48*time.Hour))
+
+			s3 := newTestHelper(server.Server)
+			aborted, err := s3.AbortIncompleteUploads("x43563", "dir/", 24*time.Hour)
+			So(err, ShouldBeNil)
+			So(aborted, ShouldEqual, 1)
+			So(server.uploadIDs(), ShouldResemble, []string{recent, other})
+		})
+	})
+}
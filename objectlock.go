@@ -0,0 +1,127 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Retention modes of the object lock.
+const (
+	RetentionGovernance = "GOVERNANCE"
+	RetentionCompliance = "COMPLIANCE"
+)
+
+// Validity units of the default retention.
+const (
+	ValidityDays  = "DAYS"
+	ValidityYears = "YEARS"
+)
+
+// objectLockConfig represents the object lock configuration of a bucket.
+type objectLockConfig struct {
+	XMLName           xml.Name        `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ObjectLockConfiguration"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled"`
+	Rule              *objectLockRule `xml:"Rule,omitempty"`
+}
+
+// objectLockRule is the default retention rule of the object lock.
+type objectLockRule struct {
+	DefaultRetention struct {
+		Mode  string `xml:"Mode"`
+		Days  int    `xml:"Days,omitempty"`
+		Years int    `xml:"Years,omitempty"`
+	} `xml:"DefaultRetention"`
+}
+
+// GetObjectLockConfig returns the object lock configuration of the bucket:
+// whether the object lock is enabled, and the mode and the validity of the
+// default retention. The mode is empty if there is no default retention.
+func (s helper) GetObjectLockConfig(bucket string) (bool, string, int, string, error) {
+	if !s.Enabled {
+		return false, "", 0, "", ErrServerDisabled
+	}
+
+	resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"object-lock": {""}},
+	})
+	if minio.ToErrorResponse(err).Code == "ObjectLockConfigurationNotFoundError" {
+		return false, "", 0, "", nil
+	}
+	if err != nil {
+		return false, "", 0, "", errors.Wrap(err, "GetObjectLockConfig failed")
+	}
+	defer resp.Body.Close()
+
+	config := objectLockConfig{}
+	err = xml.NewDecoder(resp.Body).Decode(&config)
+	if err != nil {
+		return false, "", 0, "", errors.Wrap(err, "xml.Decode failed")
+	}
+
+	enabled := config.ObjectLockEnabled == "Enabled"
+	if config.Rule == nil {
+		return enabled, "", 0, "", nil
+	}
+
+	retention := config.Rule.DefaultRetention
+	if retention.Years > 0 {
+		return enabled, retention.Mode, retention.Years, ValidityYears, nil
+	}
+	return enabled, retention.Mode, retention.Days, ValidityDays, nil
+}
+
+// SetObjectLockConfig enables the object lock of the bucket with the given
+// default retention. An empty mode sets no default retention. The object lock
+// can only be enabled on buckets created with it.
+func (s helper) SetObjectLockConfig(bucket, mode string, validity int, unit string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	config := objectLockConfig{ObjectLockEnabled: "Enabled"}
+	if mode != "" {
+		err := validation.Validate(mode, validation.In(RetentionGovernance, RetentionCompliance))
+		if err != nil {
+			return errors.Wrap(err, "invalid mode")
+		}
+		err = validation.Validate(validity, validation.Required, validation.Min(1))
+		if err != nil {
+			return errors.Wrap(err, "invalid validity")
+		}
+		err = validation.Validate(unit, validation.Required, validation.In(ValidityDays, ValidityYears))
+		if err != nil {
+			return errors.Wrap(err, "invalid unit")
+		}
+
+		config.Rule = &objectLockRule{}
+		config.Rule.DefaultRetention.Mode = mode
+		if unit == ValidityYears {
+			config.Rule.DefaultRetention.Years = validity
+		} else {
+			config.Rule.DefaultRetention.Days = validity
+		}
+	}
+
+	content, err := xml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "xml.Marshal failed")
+	}
+
+	resp, err := s.executeMethod(context.Background(), "PUT", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"object-lock": {""}},
+		content:     content,
+	})
+	if err != nil {
+		return errors.Wrap(err, "SetObjectLockConfig failed")
+	}
+	resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestObjectLock(t *testing.T) {
+	Convey("GetObjectLockConfig", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, _, _, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Configured", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<ObjectLockConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <ObjectLockEnabled>Enabled</ObjectLockEnabled>
+  <Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Years>7</Years></DefaultRetention></Rule>
+</ObjectLockConfiguration>`))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			enabled, mode, validity, unit, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldBeNil)
+			So(enabled, ShouldBeTrue)
+			So(mode, ShouldEqual, RetentionCompliance)
+			So(validity, ShouldEqual, 7)
+			So(unit, ShouldEqual, ValidityYears)
+		})
+
+		Convey("Without retention", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<ObjectLockConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			enabled, mode, validity, _, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldBeNil)
+			So(enabled, ShouldBeTrue)
+			So(mode, ShouldBeEmpty)
+			So(validity, ShouldEqual, 0)
+		})
+
+		Convey("Not configured", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`<Error><Code>ObjectLockConfigurationNotFoundError</Code></Error>`))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			enabled, _, _, _, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldBeNil)
+			So(enabled, ShouldBeFalse)
+		})
+	})
+
+	Convey("SetObjectLockConfig", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.SetObjectLockConfig("x43563", RetentionGovernance, 1, ValidityDays)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			err := s3.SetObjectLockConfig("x43563", "FOREVER", 1, ValidityDays)
+			So(err, ShouldNotBeNil)
+			err = s3.SetObjectLockConfig("x43563", RetentionGovernance, 0, ValidityDays)
+			So(err, ShouldNotBeNil)
+			err = s3.SetObjectLockConfig("x43563", RetentionGovernance, 1, "WEEKS")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var body string
+			var query map[string][]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query()
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.SetObjectLockConfig("x43563", RetentionGovernance, 30, ValidityDays)
+			So(err, ShouldBeNil)
+			So(query, ShouldContainKey, "object-lock")
+			So(body, ShouldContainSubstring, "<ObjectLockEnabled>Enabled</ObjectLockEnabled>")
+			So(body, ShouldContainSubstring, "<Mode>GOVERNANCE</Mode><Days>30</Days>")
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package s3
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/pkg/errors"
+)
+
+// headerExpectedBucketOwner makes the server reject the requests to buckets
+// owned by another account.
+const headerExpectedBucketOwner = "X-Amz-Expected-Bucket-Owner"
+
+// streamingPayload is the content hash of the uploads signed chunk by chunk.
+const streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+// credentialRegexp extracts the region from the credential scope of a
+// signature v4 Authorization header.
+var credentialRegexp = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=[^/]*/[^/]*/([^/]*)/`)
+
+// validateExpectedBucketOwner checks that the uploads can carry the expected
+// bucket owner. The chunk signed uploads, which minio sends to the endpoints
+// without SSL with the signature version 4, can not be signed again, as their
+// chunk signatures depend on the original signature.
+func (c Config) validateExpectedBucketOwner(value interface{}) error {
+	if value.(string) != "" && !c.SSL && !c.SignatureV2 {
+		return errors.New("requires ssl with the signature version 4")
+	}
+	return nil
+}
+
+// ownerTransport adds the expected bucket owner header to the requests and
+// signs them again with the same signature version and credentials, as every
+// x-amz-* header must be signed.
+type ownerTransport struct {
+	transport http.RoundTripper
+	owner     string
+	signer    signer
+}
+
+// newOwnerTransport wraps the transport to send the expected bucket owner.
+func newOwnerTransport(transport http.RoundTripper, config Config) *ownerTransport {
+	return &ownerTransport{
+		transport: transport,
+		owner:     config.ExpectedBucketOwner,
+		signer:    newSigner(config),
+	}
+}
+
+// signer signs the requests again after a transport changed their headers.
+type signer struct {
+	endpoint        string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+// newSigner creates a signer with the credentials of the config.
+func newSigner(config Config) signer {
+	return signer{
+		endpoint:        config.Endpoint,
+		accessKeyID:     config.AccessKeyID,
+		secretAccessKey: config.SecretAccessKey,
+	}
+}
+
+// sign signs the changed copy of the request with the signature version of
+// the original request. The unsigned requests are returned as they are.
+func (s signer) sign(signed http.Request, original *http.Request) *http.Request {
+	authorization := original.Header.Get("Authorization")
+	if match := credentialRegexp.FindStringSubmatch(authorization); match != nil {
+		return s3signer.SignV4(signed, s.accessKeyID, s.secretAccessKey, original.Header.Get("X-Amz-Security-Token"), match[1])
+	}
+	if strings.HasPrefix(authorization, "AWS ") {
+		// The bucket is part of the host of the virtual host style requests.
+		virtualHost := original.URL.Host != s.endpoint
+		return s3signer.SignV2(signed, s.accessKeyID, s.secretAccessKey, virtualHost)
+	}
+
+	return &signed
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ownerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed := *req
+	signed.Header = http.Header{}
+	for k, v := range req.Header {
+		signed.Header[k] = v
+	}
+	signed.Header.Set(headerExpectedBucketOwner, t.owner)
+
+	return t.transport.RoundTrip(t.signer.sign(signed, req))
+}
@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExpectedBucketOwner(t *testing.T) {
+	Convey("ExpectedBucketOwner", t, func() {
+		var header http.Header
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		}))
+		defer server.Close()
+
+		config := testConfig(server)
+		config.Endpoint = strings.TrimPrefix(server.URL, "https://")
+		config.SSL = true
+
+		Convey("Not set", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			trustServer(s3, server)
+
+			_, _, err = s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(header.Get("X-Amz-Expected-Bucket-Owner"), ShouldBeEmpty)
+		})
+
+		Convey("Set", func() {
+			config.ExpectedBucketOwner = "123456789012"
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			trustServer(s3, server)
+
+			_, _, err = s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(header.Get("X-Amz-Expected-Bucket-Owner"), ShouldEqual, "123456789012")
+			So(header.Get("Authorization"), ShouldContainSubstring, "x-amz-expected-bucket-owner")
+			So(header.Get("Authorization"), ShouldContainSubstring, "/x/s3/aws4_request")
+
+			err = s3.RestoreObject("x43563", "dir", "file.txt", 1, TierBulk)
+			So(err, ShouldBeNil)
+			So(header.Get("X-Amz-Expected-Bucket-Owner"), ShouldEqual, "123456789012")
+		})
+
+		Convey("Upload", func() {
+			config.ExpectedBucketOwner = "123456789012"
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			trustServer(s3, server)
+
+			err = s3.CreateFile("x43563", "dir", "file.txt", strings.NewReader("asdf"), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(header.Get("X-Amz-Expected-Bucket-Owner"), ShouldEqual, "123456789012")
+			So(header.Get("Authorization"), ShouldContainSubstring, "x-amz-expected-bucket-owner")
+		})
+
+		Convey("Without SSL", func() {
+			config.ExpectedBucketOwner = "123456789012"
+			config.SSL = false
+			_, err := New(config)
+			So(err, ShouldNotBeNil)
+
+			config.SignatureV2 = true
+			_, err = New(config)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Signature V2", func() {
+			config.ExpectedBucketOwner = "123456789012"
+			config.SignatureV2 = true
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			trustServer(s3, server)
+
+			_, _, err = s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(header.Get("X-Amz-Expected-Bucket-Owner"), ShouldEqual, "123456789012")
+			So(header.Get("Authorization"), ShouldStartWith, "AWS x:")
+
+			err = s3.RestoreObject("x43563", "dir", "file.txt", 1, TierBulk)
+			So(err, ShouldBeNil)
+			So(header.Get("Authorization"), ShouldStartWith, "AWS x:")
+		})
+	})
+}
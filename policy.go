@@ -0,0 +1,275 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// policyVersion is the version of the policy language.
+const policyVersion = "2012-10-17"
+
+// policyMu serializes the policy updates of the process.
+var policyMu sync.Mutex
+
+// policyCache records the public read statements which are known to be in
+// the bucket policies, so they are set at most once per bucket and prefix in
+// the process. A nil cache is valid and caches nothing.
+type policyCache struct {
+	// updateMu serializes the cached updates, so concurrent calls for the
+	// same statement wait for the first one instead of repeating it.
+	updateMu sync.Mutex
+
+	mu        sync.Mutex
+	resources map[string]map[string]bool
+}
+
+// newPolicyCache creates a new policy cache.
+func newPolicyCache() *policyCache {
+	return &policyCache{
+		resources: map[string]map[string]bool{},
+	}
+}
+
+// has checks whether the resource is known to be public in the bucket policy.
+func (c *policyCache) has(bucket, resource string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resources[bucket][resource]
+}
+
+// set records that the resource is public in the bucket policy.
+func (c *policyCache) set(bucket, resource string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resources[bucket] == nil {
+		c.resources[bucket] = map[string]bool{}
+	}
+	c.resources[bucket][resource] = true
+}
+
+// forget drops the records of the bucket, as its policy was rewritten.
+func (c *policyCache) forget(bucket string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.resources, bucket)
+}
+
+// lock serializes the cached updates.
+func (c *policyCache) lock() {
+	if c != nil {
+		c.updateMu.Lock()
+	}
+}
+
+// unlock releases the lock taken by lock.
+func (c *policyCache) unlock() {
+	if c != nil {
+		c.updateMu.Unlock()
+	}
+}
+
+// BucketPolicyDoc represents a bucket policy document.
+type BucketPolicyDoc struct {
+	Version   string            `json:"Version,omitempty"`
+	ID        string            `json:"Id,omitempty"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// PolicyStatement represents a statement of a bucket policy. The principals
+// are kept as they are, either "*" or a map like {"AWS": ["*"]}.
+type PolicyStatement struct {
+	Sid          string                            `json:"Sid,omitempty"`
+	Effect       string                            `json:"Effect"`
+	Principal    interface{}                       `json:"Principal,omitempty"`
+	NotPrincipal interface{}                       `json:"NotPrincipal,omitempty"`
+	Action       StringList                        `json:"Action,omitempty"`
+	NotAction    StringList                        `json:"NotAction,omitempty"`
+	Resource     StringList                        `json:"Resource,omitempty"`
+	NotResource  StringList                        `json:"NotResource,omitempty"`
+	Condition    map[string]map[string]interface{} `json:"Condition,omitempty"`
+}
+
+// StringList is a list of strings which can be a single string in the JSON.
+type StringList []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+
+	var list []string
+	err := json.Unmarshal(data, &list)
+	if err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Contains checks whether the list contains the value.
+func (l StringList) Contains(value string) bool {
+	for _, v := range l {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublic checks whether the statement applies to everyone.
+func (s PolicyStatement) isPublic() bool {
+	switch principal := s.Principal.(type) {
+	case string:
+		return principal == "*"
+	case map[string]interface{}:
+		switch aws := principal["AWS"].(type) {
+		case string:
+			return aws == "*"
+		case []interface{}:
+			for _, v := range aws {
+				if v == "*" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// getBucketPolicy returns the policy of the bucket, or an empty policy if the
+// bucket has none.
+func (s helper) getBucketPolicy(bucket string) (BucketPolicyDoc, error) {
+	doc := BucketPolicyDoc{}
+
+	policy, err := s.Client.GetBucketPolicy(bucket)
+	if err != nil {
+		return doc, errors.Wrap(err, "GetBucketPolicy failed")
+	}
+	if policy == "" {
+		return doc, nil
+	}
+
+	err = json.Unmarshal([]byte(policy), &doc)
+	if err != nil {
+		return doc, errors.Wrap(err, "json.Unmarshal failed")
+	}
+
+	return doc, nil
+}
+
+// setBucketPolicy sets the policy of the bucket. A policy without statements
+// removes the policy of the bucket.
+func (s helper) setBucketPolicy(bucket string, doc BucketPolicyDoc) error {
+	if len(doc.Statement) == 0 {
+		return errors.Wrap(s.Client.SetBucketPolicy(bucket, ""), "SetBucketPolicy failed")
+	}
+
+	if doc.Version == "" {
+		doc.Version = policyVersion
+	}
+
+	policy, err := json.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal failed")
+	}
+
+	return errors.Wrap(s.Client.SetBucketPolicy(bucket, string(policy)), "SetBucketPolicy failed")
+}
+
+// UpdateBucketPolicy reads the policy of the bucket, calls edit with it and
+// writes back the edited policy. The policy is not written if edit returns an
+// error or leaves the policy unchanged. The updates are serialized within the
+// process.
+func (s helper) UpdateBucketPolicy(bucket string, edit func(policy *BucketPolicyDoc) error) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	policyMu.Lock()
+	defer policyMu.Unlock()
+
+	doc, err := s.getBucketPolicy(bucket)
+	if err != nil {
+		return err
+	}
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal failed")
+	}
+
+	err = edit(&doc)
+	if err != nil {
+		return err
+	}
+
+	edited, err := json.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal failed")
+	}
+	if bytes.Equal(original, edited) {
+		return nil
+	}
+
+	s.policies.forget(bucket)
+	return s.setBucketPolicy(bucket, doc)
+}
+
+// MakePrefixPublicRead allows everyone to read the objects under the prefix.
+// The statement is added to the existing policy of the bucket, unless it is
+// there already. The policy is checked once per bucket and prefix in the
+// process, the later calls return without a request until the policy of the
+// bucket is rewritten with UpdateBucketPolicy.
+func (s helper) MakePrefixPublicRead(bucket, prefix string) error {
+	resource := "arn:aws:s3:::" + bucket + "/*"
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		resource = "arn:aws:s3:::" + bucket + "/" + prefix + "/*"
+	}
+
+	s.policies.lock()
+	defer s.policies.unlock()
+	if s.policies.has(bucket, resource) {
+		return nil
+	}
+
+	err := s.UpdateBucketPolicy(bucket, func(doc *BucketPolicyDoc) error {
+		for _, statement := range doc.Statement {
+			if statement.Effect == "Allow" && statement.isPublic() &&
+				statement.Action.Contains("s3:GetObject") && statement.Resource.Contains(resource) {
+				return nil
+			}
+		}
+
+		doc.Statement = append(doc.Statement, PolicyStatement{
+			Effect:    "Allow",
+			Principal: map[string]interface{}{"AWS": []interface{}{"*"}},
+			Action:    StringList{"s3:GetObject"},
+			Resource:  StringList{resource},
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.policies.set(bucket, resource)
+	return nil
+}
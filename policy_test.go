@@ -0,0 +1,193 @@
+package s3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const existingPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "Uploader",
+      "Effect": "Allow",
+      "Principal": {"AWS": "arn:aws:iam::123456789012:user/uploader"},
+      "Action": "s3:PutObject",
+      "Resource": "arn:aws:s3:::x43563/uploads/*"
+    }
+  ]
+}`
+
+func TestPolicy(t *testing.T) {
+	Convey("MakePrefixPublicRead", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.MakePrefixPublicRead("x43563", "public")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		policy := func() BucketPolicyDoc {
+			doc := BucketPolicyDoc{}
+			err := json.Unmarshal([]byte(server.policy("x43563")), &doc)
+			So(err, ShouldBeNil)
+			return doc
+		}
+
+		Convey("No policy", func() {
+			err := s3.MakePrefixPublicRead("x43563", "public/")
+			So(err, ShouldBeNil)
+
+			doc := policy()
+			So(doc.Version, ShouldEqual, "2012-10-17")
+			So(doc.Statement, ShouldHaveLength, 1)
+			So(doc.Statement[0].Effect, ShouldEqual, "Allow")
+			So(doc.Statement[0].Action, ShouldResemble, StringList{"s3:GetObject"})
+			So(doc.Statement[0].Resource, ShouldResemble, StringList{"arn:aws:s3:::x43563/public/*"})
+		})
+
+		Convey("Merge", func() {
+			server.setPolicy("x43563", existingPolicy)
+
+			err := s3.MakePrefixPublicRead("x43563", "public")
+			So(err, ShouldBeNil)
+
+			doc := policy()
+			So(doc.Statement, ShouldHaveLength, 2)
+			So(doc.Statement[0].Sid, ShouldEqual, "Uploader")
+			So(doc.Statement[0].Action, ShouldResemble, StringList{"s3:PutObject"})
+			So(doc.Statement[0].Principal, ShouldResemble, map[string]interface{}{"AWS": "arn:aws:iam::123456789012:user/uploader"})
+			So(doc.Statement[1].Resource, ShouldResemble, StringList{"arn:aws:s3:::x43563/public/*"})
+		})
+
+		Convey("Already public", func() {
+			err := s3.MakePrefixPublicRead("x43563", "public")
+			So(err, ShouldBeNil)
+			err = s3.MakePrefixPublicRead("x43563", "public")
+			So(err, ShouldBeNil)
+
+			So(policy().Statement, ShouldHaveLength, 1)
+			So(server.count("PUT"), ShouldEqual, 1)
+		})
+
+		Convey("Concurrent", func() {
+			errs := make(chan error, 20)
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs <- s3.CreateFile("x43563", "public", fmt.Sprintf("%d.txt", i), strings.NewReader("asdf"), 4, "text/plain")
+					errs <- s3.MakePrefixPublicRead("x43563", "public")
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				So(err, ShouldBeNil)
+			}
+
+			policyRequests := 0
+			for _, r := range server.received() {
+				if _, ok := r.Query["policy"]; ok {
+					policyRequests++
+				}
+			}
+			So(policyRequests, ShouldEqual, 2)
+			So(server.keys("x43563"), ShouldHaveLength, 10)
+		})
+
+		Convey("Rewritten policy", func() {
+			err := s3.MakePrefixPublicRead("x43563", "public")
+			So(err, ShouldBeNil)
+			err = s3.UpdateBucketPolicy("x43563", func(policy *BucketPolicyDoc) error {
+				policy.Statement = nil
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			err = s3.MakePrefixPublicRead("x43563", "public")
+			So(err, ShouldBeNil)
+			So(policy().Statement, ShouldHaveLength, 1)
+		})
+	})
+
+	Convey("UpdateBucketPolicy", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.UpdateBucketPolicy("x43563", func(policy *BucketPolicyDoc) error {
+				return nil
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.setPolicy("x43563", existingPolicy)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Add statement", func() {
+			err := s3.UpdateBucketPolicy("x43563", func(policy *BucketPolicyDoc) error {
+				So(policy.Statement, ShouldHaveLength, 1)
+				policy.Statement = append(policy.Statement, PolicyStatement{
+					Sid:       "Reader",
+					Effect:    "Allow",
+					Principal: "*",
+					Action:    StringList{"s3:GetObject"},
+					Resource:  StringList{"arn:aws:s3:::x43563/public/*"},
+				})
+				return nil
+			})
+			So(err, ShouldBeNil)
+
+			doc := BucketPolicyDoc{}
+			err = json.Unmarshal([]byte(server.policy("x43563")), &doc)
+			So(err, ShouldBeNil)
+			So(doc.Statement, ShouldHaveLength, 2)
+			So(doc.Statement[0].Sid, ShouldEqual, "Uploader")
+			So(doc.Statement[1].Sid, ShouldEqual, "Reader")
+			So(doc.Statement[1].Principal, ShouldEqual, "*")
+		})
+
+		Convey("Remove all statements", func() {
+			err := s3.UpdateBucketPolicy("x43563", func(policy *BucketPolicyDoc) error {
+				policy.Statement = nil
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(server.policy("x43563"), ShouldBeEmpty)
+		})
+
+		Convey("Edit error", func() {
+			err := s3.UpdateBucketPolicy("x43563", func(policy *BucketPolicyDoc) error {
+				policy.Statement = nil
+				return errors.New("edit failed")
+			})
+			So(err, ShouldNotBeNil)
+			So(server.policy("x43563"), ShouldEqual, existingPolicy)
+		})
+
+		Convey("Unchanged", func() {
+			err := s3.UpdateBucketPolicy("x43563", func(policy *BucketPolicyDoc) error {
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(server.count("PUT"), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,367 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/pkg/s3utils"
+	"github.com/pkg/errors"
+)
+
+// presignConcurrency is the number of the URLs presigned at the same time.
+const presignConcurrency = 8
+
+// responseHeaders are the headers of the response which can be overridden in
+// the presigned URLs.
+var responseHeaders = []string{
+	"content-type",
+	"content-language",
+	"expires",
+	"cache-control",
+	"content-disposition",
+	"content-encoding",
+}
+
+// KeyRef references a file in a bucket.
+type KeyRef struct {
+	Directory string
+	FileName  string
+}
+
+// Key returns the object key of the file.
+func (r KeyRef) Key() string {
+	return filepath.Join(r.Directory, r.FileName)
+}
+
+// PresignedGetURLs returns presigned GET URLs for the files, keyed by the
+// object keys of the references. The URLs which could be presigned are
+// returned even if others failed, the failures are returned as BatchError.
+func (s helper) PresignedGetURLs(bucket string, keys []KeyRef, expiry time.Duration) (map[string]*url.URL, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	var mu sync.Mutex
+	urls := map[string]*url.URL{}
+	failed := BatchError{}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, presignConcurrency)
+	for _, ref := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			u, err := s.presignObject("GET", bucket, key, expiry, url.Values{})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[key] = err
+				return
+			}
+			urls[key] = u
+		}(ref.Key())
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return urls, failed
+	}
+	return urls, nil
+}
+
+// PresignedGetURLWithHeaders returns a presigned GET URL whose response carries
+// the given headers instead of the stored ones. The headers can be given as
+// Content-Disposition or as response-content-disposition.
+func (s helper) PresignedGetURLWithHeaders(bucket, directory, filename string, expiry time.Duration, respHeaders map[string]string) (*url.URL, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	params := url.Values{}
+	for k, v := range respHeaders {
+		header := strings.TrimPrefix(strings.ToLower(k), "response-")
+		if !StringList(responseHeaders).Contains(header) {
+			return nil, errors.Errorf("unsupported response header: %s", k)
+		}
+		params.Set("response-"+header, v)
+	}
+
+	u, err := s.presignObject("GET", bucket, filepath.Join(directory, filename), expiry, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "PresignedGetObject failed")
+	}
+
+	return u, nil
+}
+
+// attachmentDisposition returns the Content-Disposition of a download saved
+// as the name. The name is quoted, a name which is not ASCII is also given
+// encoded as RFC 5987 describes, with an ASCII fallback for old browsers.
+func attachmentDisposition(name string) string {
+	ascii := true
+	fallback := strings.Map(func(r rune) rune {
+		if r > 0x7e {
+			ascii = false
+			return '_'
+		}
+		return r
+	}, name)
+	fallback = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(fallback)
+
+	disposition := `attachment; filename="` + fallback + `"`
+	if ascii {
+		return disposition
+	}
+
+	encoded := ""
+	for _, b := range []byte(name) {
+		if b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || strings.IndexByte("!#$&+-.^_`|~", b) >= 0 {
+			encoded += string(b)
+		} else {
+			encoded += fmt.Sprintf("%%%02X", b)
+		}
+	}
+	return disposition + "; filename*=UTF-8''" + encoded
+}
+
+// PresignedDownloadURL returns a presigned GET URL which makes the browsers
+// download the file and save it as downloadName.
+func (s helper) PresignedDownloadURL(bucket, directory, filename, downloadName string, expiry time.Duration) (*url.URL, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	invalid := strings.IndexFunc(downloadName, func(r rune) bool {
+		return r < 0x20 || r == 0x7f || r == '/'
+	})
+	if downloadName == "" || invalid >= 0 {
+		return nil, errors.Errorf("invalid download name: %q", downloadName)
+	}
+
+	return s.PresignedGetURLWithHeaders(bucket, directory, filename, expiry, map[string]string{
+		"Content-Disposition": attachmentDisposition(downloadName),
+	})
+}
+
+// PresignedHeadURL returns a presigned HEAD URL of the file, so the size and
+// the type of the file can be checked without downloading it.
+func (s helper) PresignedHeadURL(bucket, directory, filename string, expiry time.Duration) (*url.URL, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	u, err := s.presignObject("HEAD", bucket, filepath.Join(directory, filename), expiry, url.Values{})
+	if err != nil {
+		return nil, errors.Wrap(err, "PresignedHeadObject failed")
+	}
+
+	return u, nil
+}
+
+// PresignedGetURLString returns a presigned GET URL of the file as a string,
+// e.g. to be put into HTML.
+func (s helper) PresignedGetURLString(bucket, directory, filename string, expiry time.Duration) (string, error) {
+	u, err := s.PresignedGetURLWithHeaders(bucket, directory, filename, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+// objectURL returns the unsigned path-style URL of the object.
+func (s helper) objectURL(bucket, key string) *url.URL {
+	scheme := "http"
+	if s.Config.SSL {
+		scheme = "https"
+	}
+
+	return &url.URL{
+		Scheme: scheme,
+		Host:   s.Config.Endpoint,
+		Path:   "/" + bucket + "/" + key,
+	}
+}
+
+// PublicURL returns the unsigned URL of the file, which works only if the file
+// can be read by everyone, see MakePrefixPublicRead.
+func (s helper) PublicURL(bucket, directory, filename string) (*url.URL, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	key := filepath.Join(directory, filename)
+	if err := s3utils.CheckValidBucketName(bucket); err != nil {
+		return nil, err
+	}
+	if err := s3utils.CheckValidObjectName(key); err != nil {
+		return nil, err
+	}
+
+	return s.objectURL(bucket, key), nil
+}
+
+// PublicURLString returns the unsigned URL of the file as a string, e.g. to be
+// put into HTML.
+func (s helper) PublicURLString(bucket, directory, filename string) (string, error) {
+	u, err := s.PublicURL(bucket, directory, filename)
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+// sourceIPSid returns the statement id of the source IP restriction of the
+// prefix.
+func sourceIPSid(prefix string) string {
+	sum := md5.Sum([]byte(prefix))
+	return "PresignedSourceIp" + hex.EncodeToString(sum[:])
+}
+
+// RestrictPresignedSourceIP restricts the presigned GET URLs of the files
+// under the prefix to the given IP addresses or CIDR ranges. The presigned
+// URLs can not carry conditions, so a statement of the bucket policy denies
+// the presigned requests from any other address. It is a one-time setup of
+// the bucket, not to be called per URL, which PresignedGetURLFromIP requires:
+// calling it again replaces the restriction of the prefix, no sources remove
+// it. Only the servers which
+// support the aws:SourceIp and the s3:authType policy conditions, like AWS S3,
+// enforce the restriction.
+func (s helper) RestrictPresignedSourceIP(bucket, prefix string, sourceIPs ...string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	for _, sourceIP := range sourceIPs {
+		if net.ParseIP(sourceIP) == nil {
+			if _, _, err := net.ParseCIDR(sourceIP); err != nil {
+				return errors.Errorf("invalid source IP: %s", sourceIP)
+			}
+		}
+	}
+
+	statement := PolicyStatement{
+		Sid:       sourceIPSid(prefix),
+		Effect:    "Deny",
+		Principal: "*",
+		Action:    StringList{"s3:GetObject"},
+		Resource:  StringList{"arn:aws:s3:::" + bucket + "/" + prefix + "*"},
+		Condition: map[string]map[string]interface{}{
+			"NotIpAddress": {"aws:SourceIp": sourceIPs},
+			"StringEquals": {"s3:authType": "REST-QUERY-STRING"},
+		},
+	}
+
+	return s.UpdateBucketPolicy(bucket, func(doc *BucketPolicyDoc) error {
+		statements := []PolicyStatement{}
+		for _, existing := range doc.Statement {
+			if existing.Sid != statement.Sid {
+				statements = append(statements, existing)
+			}
+		}
+		if len(sourceIPs) > 0 {
+			statements = append(statements, statement)
+		}
+		doc.Statement = statements
+		return nil
+	})
+}
+
+// sourceAllowed checks whether the source IP is one of the sources of the
+// restriction, or is in one of its CIDR ranges.
+func sourceAllowed(sources interface{}, sourceIP net.IP) bool {
+	var list []string
+	switch v := sources.(type) {
+	case string:
+		list = []string{v}
+	case []string:
+		list = v
+	case []interface{}:
+		for _, source := range v {
+			if source, ok := source.(string); ok {
+				list = append(list, source)
+			}
+		}
+	}
+
+	for _, source := range list {
+		if ip := net.ParseIP(source); ip != nil && ip.Equal(sourceIP) {
+			return true
+		}
+		if _, ipNet, err := net.ParseCIDR(source); err == nil && ipNet.Contains(sourceIP) {
+			return true
+		}
+	}
+	return false
+}
+
+// PresignedGetURLFromIP returns a presigned GET URL of the file which works
+// only from the source IP. The presigned URLs can not carry conditions, so
+// the file must be under a prefix restricted to the source by
+// RestrictPresignedSourceIP, which is checked in the bucket policy on every
+// call. The URL works from every source the restriction allows, so a prefix
+// restricted to a single address makes a per address link. Only the servers
+// which support the aws:SourceIp and the s3:authType policy conditions, like
+// AWS S3, enforce the restriction.
+func (s helper) PresignedGetURLFromIP(bucket, directory, filename string, expiry time.Duration, sourceIP string) (*url.URL, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	ip := net.ParseIP(sourceIP)
+	if ip == nil {
+		return nil, errors.Errorf("invalid source IP: %s", sourceIP)
+	}
+
+	doc, err := s.getBucketPolicy(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	key := filepath.Join(directory, filename)
+	resourcePrefix := "arn:aws:s3:::" + bucket + "/"
+	restricted := false
+	for _, statement := range doc.Statement {
+		if !strings.HasPrefix(statement.Sid, "PresignedSourceIp") || statement.Effect != "Deny" {
+			continue
+		}
+
+		covered := false
+		for _, resource := range statement.Resource {
+			if strings.HasPrefix(resource, resourcePrefix) && strings.HasPrefix(key, strings.TrimSuffix(resource[len(resourcePrefix):], "*")) {
+				covered = true
+			}
+		}
+		if !covered {
+			continue
+		}
+
+		// Every restriction of the file must allow the source.
+		if !sourceAllowed(statement.Condition["NotIpAddress"]["aws:SourceIp"], ip) {
+			return nil, errors.Errorf("the presigned URLs of %s are not allowed from %s", key, sourceIP)
+		}
+		restricted = true
+	}
+	if !restricted {
+		return nil, errors.Errorf("the presigned URLs of %s are not restricted, see RestrictPresignedSourceIP", key)
+	}
+
+	u, err := s.presignObject("GET", bucket, key, expiry, url.Values{})
+	if err != nil {
+		return nil, errors.Wrap(err, "PresignedGetObject failed")
+	}
+
+	return u, nil
+}
@@ -0,0 +1,330 @@
+package s3
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPresign(t *testing.T) {
+	config := Config{
+		AccessKeyID:     "x",
+		Endpoint:        "localhost",
+		Region:          "x",
+		SecretAccessKey: "x",
+		BucketName:      "x",
+		SSL:             false,
+	}
+
+	Convey("PresignedGetURLs", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.PresignedGetURLs("x43563", []KeyRef{{Directory: "dir", FileName: "a.jpg"}}, time.Hour)
+			So(err, ShouldNotBeNil)
+		})
+
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Success", func() {
+			keys := []KeyRef{}
+			for _, name := range []string{"a.jpg", "b.jpg", "c.jpg", "d.jpg", "e.jpg", "f.jpg", "g.jpg", "h.jpg", "i.jpg", "j.jpg"} {
+				keys = append(keys, KeyRef{Directory: "thumbs", FileName: name})
+			}
+
+			urls, err := s3.PresignedGetURLs("x43563", keys, time.Hour)
+			So(err, ShouldBeNil)
+			So(urls, ShouldHaveLength, len(keys))
+
+			u := urls["thumbs/a.jpg"]
+			So(u, ShouldNotBeNil)
+			So(u.Host, ShouldEqual, "localhost")
+			So(u.Path, ShouldEqual, "/x43563/thumbs/a.jpg")
+			So(u.Query().Get("X-Amz-Expires"), ShouldEqual, "3600")
+			So(u.Query().Get("X-Amz-Signature"), ShouldNotBeEmpty)
+		})
+
+		Convey("Partial failure", func() {
+			keys := []KeyRef{
+				{Directory: "thumbs", FileName: "a.jpg"},
+				{Directory: "", FileName: ""},
+			}
+
+			urls, err := s3.PresignedGetURLs("x43563", keys, time.Hour)
+			So(err, ShouldNotBeNil)
+			So(urls, ShouldHaveLength, 1)
+			So(urls, ShouldContainKey, "thumbs/a.jpg")
+
+			batchErr, ok := err.(BatchError)
+			So(ok, ShouldBeTrue)
+			So(batchErr, ShouldHaveLength, 1)
+			So(batchErr, ShouldContainKey, "")
+		})
+	})
+
+	Convey("PresignedGetURLWithHeaders", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.PresignedGetURLWithHeaders("x43563", "dir", "file.pdf", time.Hour, nil)
+			So(err, ShouldNotBeNil)
+		})
+
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Success", func() {
+			u, err := s3.PresignedGetURLWithHeaders("x43563", "dir", "file.pdf", time.Hour, map[string]string{
+				"Content-Disposition":   `attachment; filename="report.pdf"`,
+				"response-content-type": "application/pdf",
+			})
+			So(err, ShouldBeNil)
+			So(u.Path, ShouldEqual, "/x43563/dir/file.pdf")
+			So(u.Query().Get("response-content-disposition"), ShouldEqual, `attachment; filename="report.pdf"`)
+			So(u.Query().Get("response-content-type"), ShouldEqual, "application/pdf")
+			So(u.Query().Get("X-Amz-Signature"), ShouldNotBeEmpty)
+		})
+
+		Convey("Unsupported header", func() {
+			_, err := s3.PresignedGetURLWithHeaders("x43563", "dir", "file.pdf", time.Hour, map[string]string{
+				"X-Custom": "value",
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("PresignedDownloadURL", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.PresignedDownloadURL("x43563", "dir", "file.pdf", "report.pdf", time.Hour)
+			So(err, ShouldNotBeNil)
+		})
+
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		disposition := func(downloadName string) string {
+			u, err := s3.PresignedDownloadURL("x43563", "dir", "file.pdf", downloadName, time.Hour)
+			So(err, ShouldBeNil)
+			So(u.Path, ShouldEqual, "/x43563/dir/file.pdf")
+			So(u.Query().Get("X-Amz-Signature"), ShouldNotBeEmpty)
+			return u.Query().Get("response-content-disposition")
+		}
+
+		Convey("ASCII name", func() {
+			So(disposition("report 2019.pdf"), ShouldEqual, `attachment; filename="report 2019.pdf"`)
+			So(disposition(`say "hi" \ bye.txt`), ShouldEqual, `attachment; filename="say \"hi\" \\ bye.txt"`)
+		})
+
+		Convey("Unicode name", func() {
+			So(disposition("jelentés ő.pdf"), ShouldEqual, `attachment; filename="jelent_s _.pdf"; filename*=UTF-8''jelent%C3%A9s%20%C5%91.pdf`)
+		})
+
+		Convey("Invalid name", func() {
+			for _, name := range []string{"", "dir/report.pdf", "report\n.pdf"} {
+				_, err := s3.PresignedDownloadURL("x43563", "dir", "file.pdf", name, time.Hour)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+
+	Convey("PresignedHeadURL", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.PresignedHeadURL("x43563", "dir", "file.pdf", time.Hour)
+			So(err, ShouldNotBeNil)
+		})
+
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Success", func() {
+			u, err := s3.PresignedHeadURL("x43563", "dir", "file.pdf", time.Hour)
+			So(err, ShouldBeNil)
+			So(u.Path, ShouldEqual, "/x43563/dir/file.pdf")
+
+			// The signature covers the method.
+			date, err := time.Parse(iso8601DateFormat, u.Query().Get("X-Amz-Date"))
+			So(err, ShouldBeNil)
+			h := s3.(*helper)
+			So(u.String(), ShouldEqual, h.presignV4("HEAD", "x43563", "dir/file.pdf", time.Hour, nil, date).String())
+			So(u.String(), ShouldNotEqual, h.presignV4("GET", "x43563", "dir/file.pdf", time.Hour, nil, date).String())
+		})
+	})
+
+	Convey("URL strings", t, func() {
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+		h := s3.(*helper)
+
+		Convey("Presigned", func() {
+			str, err := s3.PresignedGetURLString("x43563", "dir", "file name.pdf", time.Hour)
+			So(err, ShouldBeNil)
+
+			u, err := url.Parse(str)
+			So(err, ShouldBeNil)
+			date, err := time.Parse(iso8601DateFormat, u.Query().Get("X-Amz-Date"))
+			So(err, ShouldBeNil)
+			So(str, ShouldEqual, h.presignV4("GET", "x43563", "dir/file name.pdf", time.Hour, nil, date).String())
+		})
+
+		Convey("Public", func() {
+			u, err := s3.PublicURL("x43563", "dir", "file name.pdf")
+			So(err, ShouldBeNil)
+			So(u.Path, ShouldEqual, "/x43563/dir/file name.pdf")
+			So(u.RawQuery, ShouldBeEmpty)
+
+			str, err := s3.PublicURLString("x43563", "dir", "file name.pdf")
+			So(err, ShouldBeNil)
+			So(str, ShouldEqual, u.String())
+
+			_, err = s3.PublicURLString("x", "dir", "file.pdf")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("SignatureV2", t, func() {
+		config.SignatureV2 = true
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		urls, err := s3.PresignedGetURLs("x43563", []KeyRef{{Directory: "dir", FileName: "a.jpg"}}, time.Hour)
+		So(err, ShouldBeNil)
+
+		query := urls["dir/a.jpg"].Query()
+		So(query.Get("AWSAccessKeyId"), ShouldEqual, "x")
+		So(query.Get("Signature"), ShouldNotBeEmpty)
+		So(query.Get("X-Amz-Signature"), ShouldBeEmpty)
+	})
+
+	Convey("RestrictPresignedSourceIP", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.RestrictPresignedSourceIP("x43563", "dir/", "203.0.113.7")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.setPolicy("x43563", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::x43563/public/*"}]}`)
+		s3 := newTestHelper(server.Server)
+
+		statements := func() []PolicyStatement {
+			doc := BucketPolicyDoc{}
+			err := json.Unmarshal([]byte(server.policy("x43563")), &doc)
+			So(err, ShouldBeNil)
+			return doc.Statement
+		}
+
+		Convey("Success", func() {
+			err := s3.RestrictPresignedSourceIP("x43563", "dir/", "203.0.113.7", "198.51.100.0/24")
+			So(err, ShouldBeNil)
+
+			policy := statements()
+			So(policy, ShouldHaveLength, 2)
+			So(policy[1].Effect, ShouldEqual, "Deny")
+			So(policy[1].Resource, ShouldResemble, StringList{"arn:aws:s3:::x43563/dir/*"})
+			So(policy[1].Condition["NotIpAddress"]["aws:SourceIp"], ShouldResemble, []interface{}{"203.0.113.7", "198.51.100.0/24"})
+			So(policy[1].Condition["StringEquals"]["s3:authType"], ShouldEqual, "REST-QUERY-STRING")
+
+			Convey("Replaced", func() {
+				err := s3.RestrictPresignedSourceIP("x43563", "dir/", "192.0.2.1")
+				So(err, ShouldBeNil)
+
+				policy := statements()
+				So(policy, ShouldHaveLength, 2)
+				So(policy[1].Condition["NotIpAddress"]["aws:SourceIp"], ShouldResemble, []interface{}{"192.0.2.1"})
+			})
+
+			Convey("Removed", func() {
+				err := s3.RestrictPresignedSourceIP("x43563", "dir/")
+				So(err, ShouldBeNil)
+				So(statements(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("Presigning does not change the policy", func() {
+			before := server.policy("x43563")
+			_, err := s3.PresignedGetURLString("x43563", "dir", "a.jpg", time.Hour)
+			So(err, ShouldBeNil)
+			So(server.policy("x43563"), ShouldEqual, before)
+		})
+
+		Convey("Invalid IP", func() {
+			err := s3.RestrictPresignedSourceIP("x43563", "dir/", "localhost")
+			So(err, ShouldNotBeNil)
+			So(statements(), ShouldHaveLength, 1)
+		})
+	})
+
+	Convey("PresignedGetURLFromIP", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.PresignedGetURLFromIP("x43563", "dir", "a.jpg", time.Hour, "203.0.113.7")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Restricted", func() {
+			err := s3.RestrictPresignedSourceIP("x43563", "dir/", "203.0.113.7", "198.51.100.0/24")
+			So(err, ShouldBeNil)
+
+			u, err := s3.PresignedGetURLFromIP("x43563", "dir", "a.jpg", time.Hour, "203.0.113.7")
+			So(err, ShouldBeNil)
+			So(u.Path, ShouldEqual, "/x43563/dir/a.jpg")
+			So(u.Query().Get("X-Amz-Signature"), ShouldNotBeEmpty)
+
+			_, err = s3.PresignedGetURLFromIP("x43563", "dir", "a.jpg", time.Hour, "198.51.100.20")
+			So(err, ShouldBeNil)
+
+			// The condition of the policy denies the other sources.
+			doc := BucketPolicyDoc{}
+			So(json.Unmarshal([]byte(server.policy("x43563")), &doc), ShouldBeNil)
+			So(doc.Statement[0].Condition["NotIpAddress"]["aws:SourceIp"], ShouldContain, "203.0.113.7")
+		})
+
+		Convey("Other source", func() {
+			err := s3.RestrictPresignedSourceIP("x43563", "dir/", "203.0.113.7")
+			So(err, ShouldBeNil)
+
+			_, err = s3.PresignedGetURLFromIP("x43563", "dir", "a.jpg", time.Hour, "192.0.2.1")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Not restricted", func() {
+			err := s3.RestrictPresignedSourceIP("x43563", "other/", "203.0.113.7")
+			So(err, ShouldBeNil)
+
+			_, err = s3.PresignedGetURLFromIP("x43563", "dir", "a.jpg", time.Hour, "203.0.113.7")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid IP", func() {
+			_, err := s3.PresignedGetURLFromIP("x43563", "dir", "a.jpg", time.Hour, "localhost")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
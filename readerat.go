@@ -0,0 +1,86 @@
+package s3
+
+import (
+	"io"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// objectReaderAt reads an object with ranged requests.
+type objectReaderAt struct {
+	core   minio.Core
+	bucket string
+	key    string
+	etag   string
+	size   int64
+}
+
+// ReadAt implements io.ReaderAt.
+func (r objectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	end := off + int64(len(p)) - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+
+	opts := minio.GetObjectOptions{}
+	err := opts.SetRange(off, end)
+	if err != nil {
+		return 0, err
+	}
+	// The reads must not mix the content of different uploads.
+	err = opts.SetMatchETag(r.etag)
+	if err != nil {
+		return 0, err
+	}
+
+	body, _, err := r.core.GetObject(r.bucket, r.key, opts)
+	if err != nil {
+		return 0, errors.Wrap(err, "GetObject failed")
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:end-off+1])
+	if err != nil {
+		return n, errors.Wrap(err, "read failed")
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// NewObjectReaderAt returns a reader which reads the file with a ranged request
+// on every ReadAt call, and the size of the file.
+func (s helper) NewObjectReaderAt(bucket, directory, filename string) (io.ReaderAt, int64, error) {
+	if !s.Enabled {
+		return nil, 0, ErrServerDisabled
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !found {
+		return nil, 0, errors.New("file not found")
+	}
+
+	return objectReaderAt{
+		core:   minio.Core{Client: s.Client},
+		bucket: bucket,
+		key:    filepath.Join(directory, filename),
+		etag:   info.ETag,
+		size:   info.Size,
+	}, info.Size, nil
+}
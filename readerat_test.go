@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+
+	minio "github.com/minio/minio-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReaderAt(t *testing.T) {
+	Convey("NewObjectReaderAt", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.NewObjectReaderAt("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		content := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", content, nil)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Missing file", func() {
+			_, _, err := s3.NewObjectReaderAt("x43563", "dir", "missing.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Read at offsets", func() {
+			reader, size, err := s3.NewObjectReaderAt("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, len(content))
+
+			for _, off := range []int64{0, 5, 10, 30} {
+				p := make([]byte, 6)
+				n, err := reader.ReadAt(p, off)
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 6)
+				So(string(p), ShouldEqual, string(content[off:off+6]))
+			}
+
+			Convey("Past the end", func() {
+				p := make([]byte, 10)
+				n, err := reader.ReadAt(p, 32)
+				So(err, ShouldEqual, io.EOF)
+				So(n, ShouldEqual, 4)
+				So(string(p[:n]), ShouldEqual, "wxyz")
+
+				n, err = reader.ReadAt(p, 36)
+				So(err, ShouldEqual, io.EOF)
+				So(n, ShouldEqual, 0)
+			})
+
+			Convey("Section reader", func() {
+				section := io.NewSectionReader(reader, 10, 26)
+				p := make([]byte, 26)
+				_, err := io.ReadFull(section, p)
+				So(err, ShouldBeNil)
+				So(string(p), ShouldEqual, "abcdefghijklmnopqrstuvwxyz")
+			})
+		})
+	})
+
+	Convey("GetObjectRaw", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetObjectRaw("x43563", "dir/file.txt", minio.GetObjectOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("0123456789abcdefghijklmnopqrstuvwxyz"), nil)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Range", func() {
+			opts := minio.GetObjectOptions{}
+			So(opts.SetRange(10, 15), ShouldBeNil)
+
+			obj, found, err := s3.GetObjectRaw("x43563", "/dir/file.txt", opts)
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			defer obj.Close()
+
+			data, err := ioutil.ReadAll(obj)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "abcdef")
+
+			requests := server.received()
+			So(requests[len(requests)-1].Header.Get("Range"), ShouldEqual, "bytes=10-15")
+		})
+
+		Convey("Missing file", func() {
+			obj, found, err := s3.GetObjectRaw("x43563", "dir/missing.txt", minio.GetObjectOptions{})
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+			So(obj, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,32 @@
+package s3
+
+import (
+	"github.com/pkg/errors"
+)
+
+// WithRegion returns a helper which works with the buckets of the region
+// instead of the configured one, e.g. to create a bucket in another region.
+// The helpers share the connections and the caches.
+func (s helper) WithRegion(region string) (Helper, error) {
+	return s.withRegion(region)
+}
+
+// withRegion returns a copy of the helper with the client of the region.
+func (s helper) withRegion(region string) (*helper, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	if region == "" {
+		return nil, errors.New("region must not be empty")
+	}
+
+	s.Config.Region = region
+	client, err := newClient(s.Config, s.transport)
+	if err != nil {
+		return nil, err
+	}
+	s.Client = client
+
+	return &s, nil
+}
@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegion(t *testing.T) {
+	Convey("WithRegion", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.WithRegion("eu-west-1")
+			So(err, ShouldNotBeNil)
+		})
+
+		var authorization, body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			b, _ := ioutil.ReadAll(r.Body)
+			body = string(b)
+		}))
+		defer server.Close()
+		s3 := newTestHelper(server)
+
+		Convey("Empty region", func() {
+			_, err := s3.WithRegion("")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Create bucket", func() {
+			eu, err := s3.WithRegion("eu-west-1")
+			So(err, ShouldBeNil)
+
+			err = eu.CreateBucket("x43563")
+			So(err, ShouldBeNil)
+			So(body, ShouldContainSubstring, "<LocationConstraint>eu-west-1</LocationConstraint>")
+			So(authorization, ShouldContainSubstring, "/eu-west-1/s3/aws4_request")
+
+			// The original helper keeps its region.
+			err = s3.CreateBucket("y43563")
+			So(err, ShouldBeNil)
+			So(body, ShouldNotContainSubstring, "eu-west-1")
+			So(authorization, ShouldNotContainSubstring, "eu-west-1")
+		})
+	})
+}
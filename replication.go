@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// destinationARN matches the S3 bucket ARNs (arn:aws:s3:::bucket) and the
+// MinIO replication target ARNs (arn:minio:replication::id:bucket).
+var destinationARN = regexp.MustCompile(`^arn:([a-z0-9-]+:s3:::|minio:replication:[a-z0-9-]*:[^:]+:)[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// ReplicationConfig represents the replication configuration of a bucket.
+type ReplicationConfig struct {
+	XMLName xml.Name          `xml:"ReplicationConfiguration"`
+	Role    string            `xml:"Role,omitempty"`
+	Rules   []ReplicationRule `xml:"Rule"`
+}
+
+// ReplicationRule represents a replication rule.
+type ReplicationRule struct {
+	ID          string                 `xml:"ID,omitempty"`
+	Status      string                 `xml:"Status"`
+	Prefix      string                 `xml:"Prefix"`
+	Destination ReplicationDestination `xml:"Destination"`
+}
+
+// ReplicationDestination represents the target of a replication rule.
+type ReplicationDestination struct {
+	Bucket       string `xml:"Bucket"`
+	StorageClass string `xml:"StorageClass,omitempty"`
+}
+
+// Validate validates the struct.
+func (r ReplicationRule) Validate() error {
+	return validation.ValidateStruct(
+		&r,
+		validation.Field(&r.Status, validation.Required, validation.In("Enabled", "Disabled")),
+		validation.Field(&r.Destination),
+	)
+}
+
+// Validate validates the struct.
+func (d ReplicationDestination) Validate() error {
+	return validation.ValidateStruct(
+		&d,
+		validation.Field(&d.Bucket, validation.Required, validation.Match(destinationARN)),
+	)
+}
+
+// SetBucketReplication sets the replication configuration of the bucket.
+func (s helper) SetBucketReplication(bucket string, config ReplicationConfig) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	for _, rule := range config.Rules {
+		err := rule.Validate()
+		if err != nil {
+			return errors.Wrap(err, "invalid replication rule")
+		}
+	}
+
+	content, err := xml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "xml.Marshal failed")
+	}
+
+	resp, err := s.executeMethod(context.Background(), "PUT", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"replication": {""}},
+		content:     content,
+	})
+	if err != nil {
+		return errors.Wrap(err, "SetBucketReplication failed")
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// GetBucketReplication returns the replication configuration of the bucket.
+func (s helper) GetBucketReplication(bucket string) (ReplicationConfig, error) {
+	config := ReplicationConfig{}
+	if !s.Enabled {
+		return config, ErrServerDisabled
+	}
+
+	resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"replication": {""}},
+	})
+	if err != nil {
+		return config, errors.Wrap(err, "GetBucketReplication failed")
+	}
+	defer resp.Body.Close()
+
+	err = xml.NewDecoder(resp.Body).Decode(&config)
+	if err != nil {
+		return config, errors.Wrap(err, "xml.Decode failed")
+	}
+
+	return config, nil
+}
+
+// AddReplicationRule adds an enabled rule to the replication configuration of
+// the bucket which replicates the objects under the prefix to the destination.
+func (s helper) AddReplicationRule(bucket, id, prefix, destinationARN string) error {
+	config, err := s.GetBucketReplication(bucket)
+	if minio.ToErrorResponse(errors.Cause(err)).Code == "ReplicationConfigurationNotFoundError" {
+		config, err = ReplicationConfig{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	config.Rules = append(config.Rules, ReplicationRule{
+		ID:     id,
+		Status: "Enabled",
+		Prefix: prefix,
+		Destination: ReplicationDestination{
+			Bucket: destinationARN,
+		},
+	})
+
+	return s.SetBucketReplication(bucket, config)
+}
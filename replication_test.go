@@ -0,0 +1,120 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReplication(t *testing.T) {
+	Convey("SetBucketReplication", t, func() {
+		config := ReplicationConfig{
+			Role: "arn:aws:iam::123456789012:role/replication",
+			Rules: []ReplicationRule{
+				{
+					ID:     "dr",
+					Status: "Enabled",
+					Destination: ReplicationDestination{
+						Bucket: "arn:aws:s3:::dr-bucket",
+					},
+				},
+			},
+		}
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.SetBucketReplication("x43563", config)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid destination", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			config.Rules[0].Destination.Bucket = "dr-bucket"
+			err := s3.SetBucketReplication("x43563", config)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var method, body string
+			var query map[string][]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method = r.Method
+				query = r.URL.Query()
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.SetBucketReplication("x43563", config)
+			So(err, ShouldBeNil)
+			So(method, ShouldEqual, "PUT")
+			So(query, ShouldContainKey, "replication")
+			So(body, ShouldContainSubstring, "<Role>arn:aws:iam::123456789012:role/replication</Role>")
+			So(body, ShouldContainSubstring, "<Destination><Bucket>arn:aws:s3:::dr-bucket</Bucket></Destination>")
+		})
+	})
+
+	Convey("GetBucketReplication", t, func() {
+		Convey("Success", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<ReplicationConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+					`<Role>arn:aws:iam::123456789012:role/replication</Role>` +
+					`<Rule><ID>dr</ID><Status>Enabled</Status><Prefix>images/</Prefix>` +
+					`<Destination><Bucket>arn:aws:s3:::dr-bucket</Bucket></Destination></Rule>` +
+					`</ReplicationConfiguration>`))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			config, err := s3.GetBucketReplication("x43563")
+			So(err, ShouldBeNil)
+			So(config.Role, ShouldEqual, "arn:aws:iam::123456789012:role/replication")
+			So(config.Rules, ShouldHaveLength, 1)
+			So(config.Rules[0].Prefix, ShouldEqual, "images/")
+			So(config.Rules[0].Destination.Bucket, ShouldEqual, "arn:aws:s3:::dr-bucket")
+		})
+	})
+
+	Convey("AddReplicationRule", t, func() {
+		Convey("Without existing configuration", func() {
+			var body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte("<Error><Code>ReplicationConfigurationNotFoundError</Code></Error>"))
+					return
+				}
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.AddReplicationRule("x43563", "dr", "images/", "arn:minio:replication::c5be6b16:dr-bucket")
+			So(err, ShouldBeNil)
+			So(body, ShouldContainSubstring, "<ID>dr</ID><Status>Enabled</Status><Prefix>images/</Prefix>")
+			So(body, ShouldContainSubstring, "<Bucket>arn:minio:replication::c5be6b16:dr-bucket</Bucket>")
+		})
+
+		Convey("Error", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.AddReplicationRule("x43563", "dr", "images/", "arn:aws:s3:::dr-bucket")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+
+	minio "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/pkg/errors"
+)
+
+// requestMetadata describes a request sent by executeMethod.
+type requestMetadata struct {
+	bucketName  string
+	objectName  string
+	queryValues url.Values
+	header      http.Header
+	content     []byte
+}
+
+// executeMethod signs and sends a request for the S3 APIs which are not
+// covered by the minio client. The failed requests are retried by the retry
+// transport. Responses with a non 2xx status code are
+// returned as minio.ErrorResponse, otherwise the caller must close the body.
+func (s helper) executeMethod(ctx context.Context, method string, metadata requestMetadata) (*http.Response, error) {
+	scheme := "http"
+	if s.Config.SSL {
+		scheme = "https"
+	}
+
+	path := "/"
+	if metadata.bucketName != "" {
+		path += metadata.bucketName
+		if metadata.objectName != "" {
+			path += "/" + metadata.objectName
+		}
+	}
+
+	target := url.URL{
+		Scheme:   scheme,
+		Host:     s.Config.Endpoint,
+		Path:     path,
+		RawQuery: metadata.queryValues.Encode(),
+	}
+
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(metadata.content))
+	if err != nil {
+		return nil, errors.Wrap(err, "NewRequest failed")
+	}
+	req = req.WithContext(context.WithValue(ctx, helperRequestKey{}, true))
+	req.ContentLength = int64(len(metadata.content))
+	if req.ContentLength == 0 {
+		req.Body = nil
+	}
+
+	for k, v := range metadata.header {
+		req.Header[k] = v
+	}
+
+	sum := sha256.Sum256(metadata.content)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+	if len(metadata.content) > 0 {
+		md5sum := md5.Sum(metadata.content)
+		req.Header.Set("Content-Md5", base64.StdEncoding.EncodeToString(md5sum[:]))
+	}
+
+	if s.Config.SignatureV2 {
+		req = s3signer.SignV2(*req, s.Config.AccessKeyID, s.Config.SecretAccessKey, false)
+	} else {
+		req = s3signer.SignV4(*req, s.Config.AccessKeyID, s.Config.SecretAccessKey, "", s.Config.Region)
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, toErrorResponse(resp, metadata.bucketName, metadata.objectName)
+	}
+
+	return resp, nil
+}
+
+// toErrorResponse converts the response into a minio.ErrorResponse.
+func toErrorResponse(resp *http.Response, bucket, object string) error {
+	errResp := minio.ErrorResponse{}
+	err := xml.NewDecoder(resp.Body).Decode(&errResp)
+	if err != nil || errResp.Code == "" {
+		errResp = minio.ErrorResponse{
+			Code:       resp.Status,
+			Message:    resp.Status,
+			BucketName: bucket,
+			Key:        object,
+		}
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			errResp.Code = "NoSuchKey"
+			if object == "" {
+				errResp.Code = "NoSuchBucket"
+			}
+		case http.StatusForbidden:
+			errResp.Code = "AccessDenied"
+		}
+	}
+
+	errResp.StatusCode = resp.StatusCode
+	errResp.Headers = resp.Header
+	if errResp.RequestID == "" {
+		errResp.RequestID = resp.Header.Get("x-amz-request-id")
+	}
+
+	return errResp
+}
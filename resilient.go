@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"io"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// resilientReadRetries is the number of times a dropped download is resumed
+// without reading any data in between.
+const resilientReadRetries = 3
+
+// resilientReader reads an object and resumes the download with a ranged
+// request from the read offset when it fails mid-stream.
+type resilientReader struct {
+	core    minio.Core
+	bucket  string
+	key     string
+	etag    string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+	retries int
+}
+
+// Read implements io.Reader.
+func (r *resilientReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			r.retries = 0
+		}
+		if err == nil || err == io.EOF || r.offset >= r.size {
+			return n, err
+		}
+
+		if r.retries >= resilientReadRetries {
+			return n, errors.Wrap(err, "read failed")
+		}
+		r.retries++
+
+		r.body.Close()
+		resumeErr := r.resume()
+		if resumeErr != nil {
+			r.body = errorReadCloser{err: resumeErr}
+			return n, resumeErr
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resume requests the rest of the object from the read offset. The request
+// fails if the object was changed since the download started.
+func (r *resilientReader) resume() error {
+	opts := minio.GetObjectOptions{}
+	err := opts.SetMatchETag(r.etag)
+	if err != nil {
+		return errors.Wrap(err, "SetMatchETag failed")
+	}
+	err = opts.SetRange(r.offset, 0)
+	if err != nil {
+		return errors.Wrap(err, "SetRange failed")
+	}
+
+	body, _, err := r.core.GetObject(r.bucket, r.key, opts)
+	if err != nil {
+		return errors.Wrap(err, "GetObject failed")
+	}
+	r.body = body
+	return nil
+}
+
+// Close implements io.Closer.
+func (r *resilientReader) Close() error {
+	return r.body.Close()
+}
+
+// errorReadCloser fails every read with the error.
+type errorReadCloser struct {
+	err error
+}
+
+func (e errorReadCloser) Read(p []byte) (int, error) {
+	return 0, e.err
+}
+
+func (e errorReadCloser) Close() error {
+	return nil
+}
+
+// GetFileResilient returns the content of the file. If the download drops
+// mid-stream, the reader requests the rest of the file from the last read
+// offset and continues, as long as the file is not changed in the meantime.
+// The found flag is false if the file does not exist. The caller must close
+// the reader.
+func (s helper) GetFileResilient(bucket, directory, filename string) (io.ReadCloser, bool, error) {
+	if !s.Enabled {
+		return nil, false, ErrServerDisabled
+	}
+
+	key := filepath.Join(directory, filename)
+	core := minio.Core{Client: s.Client}
+	body, info, err := core.GetObject(bucket, key, minio.GetObjectOptions{})
+	if err, ok := err.(minio.ErrorResponse); ok && (err.Code == "NoSuchKey") {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "GetObject failed")
+	}
+
+	return &resilientReader{
+		core:   core,
+		bucket: bucket,
+		key:    key,
+		etag:   info.ETag,
+		size:   info.Size,
+		body:   body,
+	}, true, nil
+}
@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// droppingServer serves the content, dropping the connection of the first
+// drops responses after half of the content.
+func droppingServer(content []byte, etag string, drops int) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	ranges := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		drop := drops > 0
+		drops--
+		mu.Unlock()
+
+		if !strings.HasSuffix(r.URL.Path, "/dir/file.bin") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && match != `"`+etag+`"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte("<Error><Code>PreconditionFailed</Code></Error>"))
+			return
+		}
+
+		start := 0
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+		}
+		w.Header().Set("ETag", `"`+etag+`"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)-start))
+		status := http.StatusOK
+		if start > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			status = http.StatusPartialContent
+		}
+		w.WriteHeader(status)
+
+		if !drop {
+			w.Write(content[start:])
+			return
+		}
+		w.Write(content[start : start+(len(content)-start)/2])
+		w.(http.Flusher).Flush()
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func TestResilient(t *testing.T) {
+	Convey("GetFileResilient", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetFileResilient("x43563", "dir", "file.bin")
+			So(err, ShouldNotBeNil)
+		})
+
+		content := bytes.Repeat([]byte("0123456789abcdef"), 1<<14)
+
+		Convey("Dropped once", func() {
+			server, ranges := droppingServer(content, "abc", 1)
+			defer server.Close()
+			s3 := newTestHelper(server)
+
+			reader, found, err := s3.GetFileResilient("x43563", "dir", "file.bin")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+
+			data, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(reader.Close(), ShouldBeNil)
+			So(data, ShouldResemble, content)
+			So(ranges(), ShouldResemble, []string{"", fmt.Sprintf("bytes=%d-", len(content)/2)})
+		})
+
+		Convey("Dropped too often", func() {
+			server, _ := droppingServer(content, "abc", 100)
+			defer server.Close()
+			s3 := newTestHelper(server)
+
+			reader, found, err := s3.GetFileResilient("x43563", "dir", "file.bin")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+
+			// The last byte is never sent, the resumed downloads make no
+			// progress.
+			data, err := ioutil.ReadAll(reader)
+			So(err, ShouldNotBeNil)
+			So(data, ShouldResemble, content[:len(content)-1])
+			reader.Close()
+		})
+
+		Convey("Missing file", func() {
+			server, _ := droppingServer(content, "abc", 0)
+			defer server.Close()
+			s3 := newTestHelper(server)
+
+			_, found, err := s3.GetFileResilient("x43563", "dir", "missing.bin")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Restore tiers of the archived objects.
+const (
+	TierStandard  = "Standard"
+	TierBulk      = "Bulk"
+	TierExpedited = "Expedited"
+)
+
+// restoreRequest represents the body of the restore object request.
+type restoreRequest struct {
+	XMLName              xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ RestoreRequest"`
+	Days                 int      `xml:"Days"`
+	GlacierJobParameters struct {
+		Tier string `xml:"Tier"`
+	} `xml:"GlacierJobParameters"`
+}
+
+// RestoreObject requests a temporary copy of an archived object for the given days.
+func (s helper) RestoreObject(bucket, directory, filename string, days int, tier string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validation.Validate(tier, validation.Required, validation.In(TierStandard, TierBulk, TierExpedited))
+	if err != nil {
+		return errors.Wrap(err, "invalid tier")
+	}
+	err = validation.Validate(days, validation.Required, validation.Min(1))
+	if err != nil {
+		return errors.Wrap(err, "invalid days")
+	}
+
+	body := restoreRequest{Days: days}
+	body.GlacierJobParameters.Tier = tier
+	content, err := xml.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "xml.Marshal failed")
+	}
+
+	resp, err := s.executeMethod(context.Background(), "POST", requestMetadata{
+		bucketName:  bucket,
+		objectName:  filepath.Join(directory, filename),
+		queryValues: url.Values{"restore": {""}},
+		content:     content,
+	})
+	if err != nil {
+		return errors.Wrap(err, "RestoreObject failed")
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// IsRestored checks whether the restored copy of an archived object is available.
+func (s helper) IsRestored(bucket, directory, filename string) (bool, error) {
+	if !s.Enabled {
+		return false, ErrServerDisabled
+	}
+
+	info, err := s.Client.StatObject(bucket, filepath.Join(directory, filename), minio.StatObjectOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "StatObject failed")
+	}
+
+	// The header looks like: ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"
+	restore := info.Metadata.Get("X-Amz-Restore")
+	return strings.Contains(restore, `ongoing-request="false"`), nil
+}
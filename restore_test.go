@@ -0,0 +1,140 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRestore(t *testing.T) {
+	Convey("RestoreObject", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.RestoreObject("x43563", "dir", "file.txt", 1, TierStandard)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid tier", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			err := s3.RestoreObject("x43563", "dir", "file.txt", 1, "Fast")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid days", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			err := s3.RestoreObject("x43563", "dir", "file.txt", 0, TierBulk)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var method, path, body string
+			var query map[string][]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method = r.Method
+				path = r.URL.Path
+				query = r.URL.Query()
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.RestoreObject("x43563", "dir", "file.txt", 3, TierExpedited)
+			So(err, ShouldBeNil)
+			So(method, ShouldEqual, "POST")
+			So(path, ShouldEqual, "/x43563/dir/file.txt")
+			So(query, ShouldContainKey, "restore")
+			So(body, ShouldContainSubstring, "<Days>3</Days>")
+			So(body, ShouldContainSubstring, "<Tier>Expedited</Tier>")
+		})
+
+		Convey("Error", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte("<Error><Code>RestoreAlreadyInProgress</Code></Error>"))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.RestoreObject("x43563", "dir", "file.txt", 3, TierBulk)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "RestoreAlreadyInProgress")
+		})
+	})
+
+	Convey("IsRestored", t, func() {
+		restoreServer := func(header string) *httptest.Server {
+			return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+				if header != "" {
+					w.Header().Set("X-Amz-Restore", header)
+				}
+			}))
+		}
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			restored, err := s3.IsRestored("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+			So(restored, ShouldBeFalse)
+		})
+
+		Convey("In progress", func() {
+			server := restoreServer(`ongoing-request="true"`)
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			restored, err := s3.IsRestored("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(restored, ShouldBeFalse)
+		})
+
+		Convey("Completed", func() {
+			server := restoreServer(`ongoing-request="false", expiry-date="Fri, 23 Dec 2012 00:00:00 GMT"`)
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			restored, err := s3.IsRestored("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(restored, ShouldBeTrue)
+		})
+
+		Convey("Not archived", func() {
+			server := restoreServer("")
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			restored, err := s3.IsRestored("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(restored, ShouldBeFalse)
+		})
+
+		Convey("Missing object", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			restored, err := s3.IsRestored("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+			So(restored, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"io"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// UploadState is the progress of a resumable upload.
+type UploadState struct {
+	Bucket    string               `json:"bucket"`
+	Key       string               `json:"key"`
+	UploadID  string               `json:"upload_id"`
+	Size      int64                `json:"size"`
+	PartSize  int64                `json:"part_size"`
+	Parts     []minio.CompletePart `json:"parts"`
+	Completed bool                 `json:"completed"`
+}
+
+// UploadStore persists the progress of the resumable uploads, so an upload
+// can be resumed after the process is restarted. LoadState returns false if
+// no state was saved with the id.
+type UploadStore interface {
+	SaveState(id string, state UploadState) error
+	LoadState(id string) (UploadState, bool, error)
+}
+
+// StartResumableUpload starts a multipart upload of the file with the given
+// size and saves its state in the store. The returned id is passed to
+// ResumeUpload, which uploads the content.
+func (s helper) StartResumableUpload(bucket, directory, filename string, size int64, mime string, store UploadStore) (string, error) {
+	if !s.Enabled {
+		return "", ErrServerDisabled
+	}
+
+	if size < 0 {
+		return "", errors.Errorf("invalid size: %d", size)
+	}
+
+	// The part size must not change when the upload is resumed, it is saved
+	// in the state.
+	partSize := int64(s.Config.UploadPartSize)
+	if partSize == 0 {
+		partSize = defaultUploadPartSize
+	}
+	partSize = scalePartSize(partSize, size)
+
+	key := s.uploadKey(directory, filename)
+	core := minio.Core{Client: s.Client}
+	uploadID, err := core.NewMultipartUpload(bucket, key, minio.PutObjectOptions{ContentType: mime})
+	if err != nil {
+		return "", errors.Wrap(err, "NewMultipartUpload failed")
+	}
+
+	err = store.SaveState(uploadID, UploadState{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uploadID,
+		Size:     size,
+		PartSize: partSize,
+		Parts:    []minio.CompletePart{},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "SaveState failed")
+	}
+
+	return uploadID, nil
+}
+
+// ResumeUpload uploads the parts of the resumable upload which are not
+// completed yet, then completes it. The content must be the same as in the
+// previous attempts, the parts are read at their offsets. The state is saved
+// after every part, a failed upload is not aborted so it can be resumed again.
+func (s helper) ResumeUpload(id string, content io.ReaderAt, store UploadStore) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	state, found, err := store.LoadState(id)
+	if err != nil {
+		return errors.Wrap(err, "LoadState failed")
+	}
+	if !found {
+		return errors.Errorf("upload not found: %s", id)
+	}
+	if state.Completed {
+		return nil
+	}
+
+	core := minio.Core{Client: s.Client}
+	for partNumber := len(state.Parts) + 1; ; partNumber++ {
+		offset := int64(partNumber-1) * state.PartSize
+		if offset >= state.Size && partNumber > 1 {
+			break
+		}
+
+		n := state.Size - offset
+		if n > state.PartSize {
+			n = state.PartSize
+		}
+
+		part, err := core.PutObjectPart(state.Bucket, state.Key, state.UploadID, partNumber, io.NewSectionReader(content, offset, n), n, "", "", nil)
+		if err != nil {
+			return errors.Wrap(err, "PutObjectPart failed")
+		}
+
+		state.Parts = append(state.Parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+		err = store.SaveState(id, state)
+		if err != nil {
+			return errors.Wrap(err, "SaveState failed")
+		}
+	}
+
+	_, err = core.CompleteMultipartUpload(state.Bucket, state.Key, state.UploadID, state.Parts)
+	if err != nil {
+		return errors.Wrap(err, "CompleteMultipartUpload failed")
+	}
+	s.cache.set(fileCacheKey(state.Bucket, state.Key), true)
+
+	state.Completed = true
+	err = store.SaveState(id, state)
+	if err != nil {
+		return errors.Wrap(err, "SaveState failed")
+	}
+
+	return nil
+}
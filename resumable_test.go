@@ -0,0 +1,127 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryStore is an UploadStore which keeps the states as JSON, like a store
+// persisting them would.
+type memoryStore map[string][]byte
+
+func (m memoryStore) SaveState(id string, state UploadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	m[id] = data
+	return nil
+}
+
+func (m memoryStore) LoadState(id string) (UploadState, bool, error) {
+	state := UploadState{}
+	data, ok := m[id]
+	if !ok {
+		return state, false, nil
+	}
+	return state, true, json.Unmarshal(data, &state)
+}
+
+// crashingStore is a memoryStore which fails after the given number of saves,
+// as if the process was killed.
+type crashingStore struct {
+	memoryStore
+	saves int
+}
+
+func (c *crashingStore) SaveState(id string, state UploadState) error {
+	if c.saves == 0 {
+		return errors.New("killed")
+	}
+	c.saves--
+	return c.memoryStore.SaveState(id, state)
+}
+
+func TestResumableUpload(t *testing.T) {
+	Convey("Resumable upload", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.StartResumableUpload("x43563", "dir", "file.bin", 4, "text/plain", memoryStore{})
+			So(err, ShouldNotBeNil)
+			err = s3.ResumeUpload("id", bytes.NewReader(nil), memoryStore{})
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		config := testConfig(server.Server)
+		config.UploadPartSize = 5 << 20
+
+		partUploads := func() int {
+			n := 0
+			for _, r := range server.received() {
+				if r.Method == "PUT" && r.Query.Get("uploadId") != "" {
+					n++
+				}
+			}
+			return n
+		}
+
+		content := bytes.Repeat([]byte("0123456789abcdef"), 12<<16)
+		store := memoryStore{}
+
+		Convey("Resume after restart", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			// The state is saved when the upload is started and after the
+			// first part, the process is killed after the second part.
+			crashing := &crashingStore{memoryStore: store, saves: 2}
+			id, err := s3.StartResumableUpload("x43563", "dir", "file.bin", int64(len(content)), "application/octet-stream", crashing)
+			So(err, ShouldBeNil)
+
+			err = s3.ResumeUpload(id, bytes.NewReader(content), crashing)
+			So(err, ShouldNotBeNil)
+			state, _, _ := store.LoadState(id)
+			So(state.Parts, ShouldHaveLength, 1)
+			So(server.uploadIDs(), ShouldResemble, []string{id})
+			So(partUploads(), ShouldEqual, 2)
+
+			// The process is restarted with a new helper.
+			s3, err = New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.ResumeUpload(id, bytes.NewReader(content), store)
+			So(err, ShouldBeNil)
+			So(partUploads(), ShouldEqual, 4)
+			So(server.uploadIDs(), ShouldBeEmpty)
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+			So(obj.etag, ShouldEndWith, "-3")
+			So(obj.header.Get("Content-Type"), ShouldEqual, "application/octet-stream")
+
+			Convey("Completed upload", func() {
+				err = s3.ResumeUpload(id, bytes.NewReader(content), store)
+				So(err, ShouldBeNil)
+				So(partUploads(), ShouldEqual, 4)
+			})
+		})
+
+		Convey("Unknown upload", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.ResumeUpload("missing", bytes.NewReader(content), store)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
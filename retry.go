@@ -0,0 +1,202 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+
+	minio "github.com/minio/minio-go"
+)
+
+// defaultMaxRetries is the number of the retries if the config does not set
+// it.
+const defaultMaxRetries = 3
+
+// defaultRetryDelay is the delay before the first retry, doubled after every
+// further attempt.
+const defaultRetryDelay = 100 * time.Millisecond
+
+// maxRetryDelay limits the growing delay, like minio-go does.
+const maxRetryDelay = 30 * time.Second
+
+// maxErrorBodySize limits the error response bodies read for the
+// classification.
+const maxErrorBodySize = 64 * 1024
+
+// retryableCodes are the error codes of the transient server errors.
+var retryableCodes = map[string]bool{
+	"InternalError":      true,
+	"RequestTimeout":     true,
+	"ServiceUnavailable": true,
+	"SlowDown":           true,
+}
+
+// isRetryable reports whether the failed request should be sent again. The
+// network errors, the transient error codes and the 5xx responses are retried.
+func isRetryable(err error) bool {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+
+	resp, ok := err.(minio.ErrorResponse)
+	if !ok {
+		return true
+	}
+	if retryableCodes[resp.Code] {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryPolicy decides which failed requests are sent again and how long to
+// wait before them: a random time up to the exponentially growing delay, so
+// the clients failed together do not retry together.
+type retryPolicy struct {
+	maxRetries  int
+	delay       time.Duration
+	maxElapsed  time.Duration
+	isRetryable func(err error) bool
+}
+
+// newRetryPolicy creates the retry policy from the config.
+func newRetryPolicy(config Config) retryPolicy {
+	p := retryPolicy{
+		maxRetries:  config.MaxRetries,
+		delay:       config.RetryDelay,
+		maxElapsed:  config.MaxRetryElapsed,
+		isRetryable: config.IsRetryable,
+	}
+	if p.maxRetries == 0 {
+		p.maxRetries = defaultMaxRetries
+	}
+	if p.delay == 0 {
+		p.delay = defaultRetryDelay
+	}
+	if p.isRetryable == nil {
+		p.isRetryable = isRetryable
+	}
+	return p
+}
+
+// backoff returns the wait before the retry after the given failed attempt,
+// counted from zero, of a request started at start. The flag is false if no
+// more retries are allowed.
+func (p retryPolicy) backoff(attempt int, start time.Time) (time.Duration, bool) {
+	if attempt >= p.maxRetries {
+		return 0, false
+	}
+
+	delay := p.delay << uint(attempt)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	wait := time.Duration(rand.Int63n(int64(delay) + 1))
+	if p.maxElapsed > 0 && time.Since(start)+wait > p.maxElapsed {
+		return 0, false
+	}
+	return wait, true
+}
+
+// helperRequestKey marks the context of the requests sent by the helper
+// itself, which are retried by the retry transport.
+type helperRequestKey struct{}
+
+// retryTransport sends the failed requests of the helper again as the retry
+// policy decides. The error responses are passed to the classifier as
+// minio.ErrorResponse. The requests of the minio client are passed through,
+// as minio-go retries them itself and every retry here would be multiplied by
+// its own ones.
+type retryTransport struct {
+	retryPolicy
+	transport http.RoundTripper
+}
+
+// newRetryTransport wraps the transport to retry the failed requests.
+func newRetryTransport(transport http.RoundTripper, policy retryPolicy) *retryTransport {
+	return &retryTransport{
+		retryPolicy: policy,
+		transport:   transport,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Value(helperRequestKey{}) == nil {
+		return t.transport.RoundTrip(req)
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	start := time.Now()
+	for attempt := 0; ; attempt++ {
+		resp, err := t.transport.RoundTrip(req)
+		wait, ok := t.backoff(attempt, start)
+		if !ok {
+			return resp, err
+		}
+
+		if err == nil {
+			if resp.StatusCode < http.StatusBadRequest {
+				return resp, nil
+			}
+			err = responseError(resp)
+		}
+		if !t.isRetryable(err) {
+			if resp != nil {
+				return resp, nil
+			}
+			return nil, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		if req.GetBody != nil {
+			retried := *req
+			retried.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = &retried
+		}
+	}
+}
+
+// responseError parses the error response. The body is read into memory and
+// replaced, so the response can still be returned to the client.
+func responseError(resp *http.Response) error {
+	errResp := minio.ErrorResponse{
+		StatusCode: resp.StatusCode,
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// A missing or unknown body leaves the code empty, the status is
+	// classified alone.
+	xml.Unmarshal(body, &errResp)
+	errResp.StatusCode = resp.StatusCode
+
+	return errResp
+}
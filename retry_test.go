@@ -0,0 +1,202 @@
+package s3
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetry(t *testing.T) {
+	Convey("isRetryable", t, func() {
+		So(isRetryable(minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusBadRequest}), ShouldBeTrue)
+		So(isRetryable(minio.ErrorResponse{StatusCode: http.StatusServiceUnavailable}), ShouldBeTrue)
+		So(isRetryable(minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}), ShouldBeFalse)
+	})
+
+	Convey("MaxRetries", t, func() {
+		requests := 0
+		status := []int{http.StatusServiceUnavailable}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests <= len(status) {
+				w.WriteHeader(status[requests-1])
+				w.Write([]byte(`<Error><Code>Busy</Code></Error>`))
+				return
+			}
+			w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			if r.Method == "GET" {
+				w.Write([]byte(`<ObjectLockConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`))
+			}
+		}))
+		defer server.Close()
+
+		config := testConfig(server)
+		config.MaxRetries = 2
+		config.RetryDelay = time.Millisecond
+
+		Convey("Retried", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			enabled, _, _, _, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldBeNil)
+			So(enabled, ShouldBeTrue)
+			So(requests, ShouldEqual, 2)
+		})
+
+		Convey("Not retryable", func() {
+			status = []int{http.StatusConflict}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			_, _, _, _, err = s3.GetObjectLockConfig("x43563")
+			So(err, ShouldNotBeNil)
+			So(requests, ShouldEqual, 1)
+		})
+
+		Convey("Unavailable", func() {
+			status = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			_, _, _, _, err = s3.GetObjectLockConfig("x43563")
+			So(err, ShouldNotBeNil)
+			So(requests, ShouldEqual, 3)
+		})
+
+		Convey("Classifier overrides", func() {
+			status = []int{http.StatusServiceUnavailable}
+			config.IsRetryable = func(err error) bool {
+				return false
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			_, _, _, _, err = s3.GetObjectLockConfig("x43563")
+			So(err, ShouldNotBeNil)
+			So(requests, ShouldEqual, 1)
+		})
+
+		Convey("Client requests", func() {
+			status = []int{http.StatusConflict}
+			classified := 0
+			config.IsRetryable = func(err error) bool {
+				classified++
+				return true
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			// The requests of the minio client are retried by minio-go.
+			_, _, err = s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+			So(requests, ShouldEqual, 1)
+			So(classified, ShouldEqual, 0)
+		})
+
+		Convey("Custom classifier", func() {
+			status = []int{http.StatusConflict}
+			var codes []string
+			config.IsRetryable = func(err error) bool {
+				code := minio.ToErrorResponse(err).Code
+				codes = append(codes, code)
+				return code == "Busy"
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			enabled, _, _, _, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldBeNil)
+			So(enabled, ShouldBeTrue)
+			So(requests, ShouldEqual, 2)
+			So(codes, ShouldResemble, []string{"Busy"})
+		})
+
+		Convey("Exhausted", func() {
+			status = []int{http.StatusConflict, http.StatusConflict, http.StatusConflict, http.StatusConflict}
+			config.IsRetryable = func(err error) bool {
+				return true
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			_, _, _, _, err = s3.GetObjectLockConfig("x43563")
+			So(err, ShouldNotBeNil)
+			So(requests, ShouldEqual, 3)
+		})
+
+		Convey("Elapsed budget", func() {
+			status = make([]int, 100)
+			for i := range status {
+				status[i] = http.StatusServiceUnavailable
+			}
+			config.MaxRetries = 100
+			config.RetryDelay = 20 * time.Millisecond
+			config.MaxRetryElapsed = 100 * time.Millisecond
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			start := time.Now()
+			_, _, _, _, err = s3.GetObjectLockConfig("x43563")
+			So(err, ShouldNotBeNil)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+			So(requests, ShouldBeGreaterThan, 1)
+			So(requests, ShouldBeLessThan, 50)
+		})
+
+		Convey("Invalid", func() {
+			config.MaxRetries = -1
+			_, err := New(config)
+			So(err, ShouldNotBeNil)
+
+			config.MaxRetries = 2
+			config.MaxRetryElapsed = -time.Second
+			_, err = New(config)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Upload parts", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		config := testConfig(server.Server)
+		config.UploadPartSize = 5 << 20
+		config.MaxRetries = 2
+		config.RetryDelay = time.Millisecond
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		parts := func() int {
+			n := 0
+			for _, r := range server.received() {
+				if r.Method == "PUT" && r.Query.Get("partNumber") != "" {
+					n++
+				}
+			}
+			return n
+		}
+
+		content := bytes.Repeat([]byte("0123456789abcdef"), 6<<16)
+
+		Convey("Retried", func() {
+			server.fail("PUT", "SlowDown", "SlowDown")
+			err := s3.CreateFile("x43563", "dir", "file.bin", bytes.NewReader(content), -1, "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(parts(), ShouldEqual, 4)
+		})
+
+		Convey("Exhausted", func() {
+			server.fail("PUT", "SlowDown", "SlowDown", "SlowDown", "SlowDown")
+			err := s3.CreateFile("x43563", "dir", "file.bin", bytes.NewReader(content), -1, "application/octet-stream")
+			So(err, ShouldNotBeNil)
+			So(parts(), ShouldEqual, 3)
+			So(server.uploadIDs(), ShouldBeEmpty)
+		})
+	})
+}
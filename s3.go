@@ -1,13 +1,19 @@
 package s3
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
 	"io"
+	"net/http"
+	"net/url"
 	"path/filepath"
 	"strings"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	minio "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
 	"github.com/pkg/errors"
 )
 
@@ -19,6 +25,109 @@ type Config struct {
 	Region          string `json:"region"`
 	SSL             bool   `json:"ssl"`
 	BucketName      string `json:"bucket_name"`
+
+	// Anonymous sends the requests unsigned, for public buckets. The static
+	// keys must be empty.
+	Anonymous bool `json:"anonymous"`
+
+	// ExistsCacheTTL enables caching the results of FileExists and
+	// BucketExists for the given duration. Zero disables the caching.
+	ExistsCacheTTL time.Duration `json:"exists_cache_ttl"`
+
+	// MaxIdleConns and MaxIdleConnsPerHost limit the idle connections kept
+	// by the client. Zero means the net/http defaults.
+	MaxIdleConns        int `json:"max_idle_conns"`
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host"`
+
+	// MinTLSVersion is the minimum TLS version accepted from the server, like
+	// tls.VersionTLS12. Zero means the crypto/tls default.
+	MinTLSVersion uint16 `json:"min_tls_version"`
+
+	// PinnedCertSHA256 are the hex SHA-256 fingerprints of the accepted
+	// server certificates. The connections to servers with any other
+	// certificate are rejected, even if it is trusted. Empty accepts any
+	// trusted certificate.
+	PinnedCertSHA256 []string `json:"pinned_cert_sha256"`
+
+	// MaxBytesPerSecond limits the transfer speed of the uploads and the
+	// downloads together. Zero means unlimited.
+	MaxBytesPerSecond int64 `json:"max_bytes_per_second"`
+
+	// TrashPrefix is the prefix of the trashed files, .trash/ by default.
+	TrashPrefix string `json:"trash_prefix"`
+
+	// DirectoryMarkerContentType is the content type of the directory markers
+	// created by CreateDirectory, text/plain by default.
+	DirectoryMarkerContentType string `json:"directory_marker_content_type"`
+
+	// KeyTemplate sets the layout of the keys of the uploads, for example
+	// {year}/{month}/{dir}/{file}. The {year}, {month} and {day} placeholders
+	// are replaced with the date of the upload in UTC, {dir} and {file} with
+	// the directory and the file name. Use ResolveKey to get the key for reads,
+	// the reads, the copies and the existence checks take the directory and
+	// the file name as they are. The uploads which check whether the file
+	// exists, CreateFileIfNotExists, GetOrCreateFile and CreateFileDedup, do
+	// not use the template either, as the date of an earlier upload is not
+	// known.
+	KeyTemplate string `json:"key_template"`
+
+	// ExpectedBucketOwner is the account id which must own the buckets. The
+	// requests to buckets of other accounts fail with access denied. It
+	// requires SSL with the signature version 4, as minio signs the uploads
+	// to the endpoints without SSL chunk by chunk, which can not carry the
+	// header.
+	ExpectedBucketOwner string `json:"expected_bucket_owner"`
+
+	// UploadPartSize is the part size of the multipart uploads, at least 5MiB.
+	// The files larger than a part and the files of unknown length are
+	// uploaded in parts. The parts of a file which would not fit into 10000
+	// parts are larger. Zero means the library default.
+	UploadPartSize uint64 `json:"upload_part_size"`
+
+	// MaxRetries is the number of times a failed request is sent again,
+	// waiting a random time up to RetryDelay (100ms by default) before the
+	// first retry and up to twice as long, at most 30s, before every further
+	// one. Zero means 3 retries, an IsRetryable which returns false disables
+	// them. The retries apply to the requests the helper sends itself, like
+	// the parts of the uploads and the S3 APIs minio-go does not cover. The
+	// requests of the minio client are retried by minio-go alone, as
+	// minio.MaxRetry sets, so a request is never retried by both.
+	MaxRetries int           `json:"max_retries"`
+	RetryDelay time.Duration `json:"retry_delay"`
+
+	// MaxRetryElapsed limits the time spent on a request with its retries.
+	// No retry is sent which would start after the limit, even if MaxRetries
+	// is not reached. Zero means no limit.
+	MaxRetryElapsed time.Duration `json:"max_retry_elapsed"`
+
+	// IsRetryable decides whether a failed request of the helper is retried,
+	// overriding the default classification. The error responses are passed as
+	// minio.ErrorResponse.
+	IsRetryable func(err error) bool `json:"-"`
+
+	// SignatureV2 signs the requests with the legacy signature version 2,
+	// required by some older S3 compatible servers. The version 4 is used by
+	// default.
+	SignatureV2 bool `json:"signature_v2"`
+
+	// Buckets maps logical names, like uploads or thumbnails, to the bucket
+	// names. Use ResolveBucket to get the bucket of a name.
+	Buckets map[string]string `json:"buckets"`
+
+	// Tracer creates a span for every operation of the helper which sends
+	// requests to the server. Nil disables the tracing.
+	Tracer Tracer `json:"-"`
+
+	// Clock returns the current time, used for the content of the directory
+	// markers and the dates of the key templates. Nil means time.Now.
+	Clock func() time.Time `json:"-"`
+
+	// AutoClockSkew corrects the clock with the one of the server when a
+	// request fails with RequestTimeTooSkewed, the request is signed again and
+	// retried once. The bucket is checked before the first presigned URL, so
+	// the URLs are valid with a skewed local clock. It requires the signature
+	// version 4, the uploads to the endpoints without SSL are not corrected.
+	AutoClockSkew bool `json:"auto_clock_skew"`
 }
 
 // Validate validates the struct.
@@ -26,34 +135,167 @@ func (c Config) Validate() error {
 	return validation.ValidateStruct(
 		&c,
 		validation.Field(&c.Endpoint, validation.Required),
-		validation.Field(&c.AccessKeyID, validation.Required),
-		validation.Field(&c.SecretAccessKey, validation.Required),
+		validation.Field(&c.AccessKeyID, validation.By(c.validateStaticKey)),
+		validation.Field(&c.SecretAccessKey, validation.By(c.validateStaticKey)),
 		validation.Field(&c.Region, validation.Required),
 		validation.Field(&c.BucketName, validation.Required),
+		validation.Field(&c.MaxIdleConns, validation.Min(0)),
+		validation.Field(&c.MaxIdleConnsPerHost, validation.Min(0)),
+		validation.Field(&c.MinTLSVersion, validation.In(uint16(tls.VersionTLS10), uint16(tls.VersionTLS11), uint16(tls.VersionTLS12), uint16(tls.VersionTLS13))),
+		validation.Field(&c.PinnedCertSHA256, validation.By(validateCertPins)),
+		validation.Field(&c.MaxBytesPerSecond, validation.Min(int64(0))),
+		validation.Field(&c.KeyTemplate, validation.Match(keyTemplateRegexp)),
+		validation.Field(&c.UploadPartSize, validation.Min(uint64(minUploadPartSize))),
+		validation.Field(&c.MaxRetries, validation.Min(0)),
+		validation.Field(&c.RetryDelay, validation.Min(time.Duration(0))),
+		validation.Field(&c.MaxRetryElapsed, validation.Min(time.Duration(0))),
+		validation.Field(&c.Buckets, validation.By(validateBuckets)),
+		validation.Field(&c.AutoClockSkew, validation.By(c.validateAutoClockSkew)),
+		validation.Field(&c.ExpectedBucketOwner, validation.By(c.validateExpectedBucketOwner)),
 	)
 }
 
-// Helper is the helper interface
+// Helper is the helper interface. When the server is not enabled, every method
+// which would send a request returns ErrServerDisabled.
 type Helper interface {
 	CreateBucket(name string) error
 	CreateDirectory(bucket string, name string) error
-	CreateFile(bucket, directory, file string, content io.Reader, length int64, mime string) error
+	CreateFile(bucket, directory, file string, content io.Reader, length int64, mime string, opts ...UploadOption) error
+	PutObject(bucket, key string, content io.Reader, length int64, opts minio.PutObjectOptions) error
+	CreateFileWithContext(ctx context.Context, bucket, directory, fileName string, content io.Reader, length int64, mime string, opts ...UploadOption) error
+	CreateFileWithStorageClass(bucket, directory, fileName string, content io.Reader, length int64, mime, storageClass string) error
+	CreateFileWithExpires(bucket, directory, fileName string, content io.Reader, length int64, mime string, expires time.Time) error
+	CreateFileWithCacheControl(bucket, directory, fileName string, content io.Reader, length int64, mime, cacheControl string) error
+	CreateFileAtomic(bucket, directory, fileName string, content io.Reader, length int64, mime string) error
+	GetOrCreateFile(bucket, directory, fileName string, content io.Reader, length int64, mime string) (minio.ObjectInfo, bool, error)
+	CreateFileIfNotExists(bucket, directory, fileName string, content io.Reader, length int64, mime string) (bool, error)
+	CreateFileFromRequest(bucket, directory, fileName string, r *http.Request) error
+	CreateFileSeekable(bucket, directory, fileName string, ra io.ReaderAt, size int64, mime string) error
+	CreateFileStream(bucket, directory, fileName string, content io.Reader, mime string) error
+	StartResumableUpload(bucket, directory, filename string, size int64, mime string, store UploadStore) (string, error)
+	ResumeUpload(id string, content io.ReaderAt, store UploadStore) error
+	CreateFileSSEC(bucket, directory, fileName string, content io.Reader, length int64, mime string, key []byte) error
+	GetFileSSEC(bucket, directory, filename string, key []byte) (*minio.Object, bool, error)
+	UploadDirectory(bucket, localDir, destPrefix string, concurrency int) (UploadResult, error)
+	DownloadDirectory(bucket, prefix, localDir string, concurrency int) (DownloadResult, error)
+	DownloadToFileParallel(bucket, directory, filename, localPath string, parts int) error
+	EnsureDirectoryMarkers(bucket, prefix string) (int, error)
+	GetObjectParts(bucket, directory, filename string) (int, error)
+	ListIncompleteUploads(bucket, prefix string) ([]minio.ObjectMultipartInfo, error)
+	AbortIncompleteUploads(bucket, prefix string, olderThan time.Duration) (int, error)
+	GetObjectLockConfig(bucket string) (bool, string, int, string, error)
+	SetObjectLockConfig(bucket, mode string, validity int, unit string) error
+	WithRegion(region string) (Helper, error)
 	GetS3Host() string
 	BucketExists(bucket string) (bool, error)
+	MustBucketExist(bucket string) error
 	ListOfBucket() ([]string, error)
-	ListOfBucketFolder(bucketName string, isRecursive bool) (*Folder, error)
+	ListBucketsDetailed() ([]minio.BucketInfo, error)
+	BucketStats(bucket string) (int64, int64, error)
+	ListOfBucketFolder(bucketName string, isRecursive bool, opts ...FolderOption) (*Folder, error)
+	ListOfBucketFolderWithContext(ctx context.Context, bucketName string, isRecursive bool, opts ...FolderOption) (*Folder, error)
 	GetBucketName() string
 	GetFile(bucket, directory, filename string) (*minio.Object, error)
+	GetObjectRaw(bucket, key string, opts minio.GetObjectOptions) (*minio.Object, bool, error)
+	Fetch(bucket, directory, filename string) (*FetchResult, error)
+	ServeFile(w http.ResponseWriter, r *http.Request, bucket, directory, filename string) error
 	FileExists(bucket, directory, filename string) (bool, error)
+	FileExistsConsistent(bucket, directory, filename string, retries int, delay time.Duration) (bool, error)
+	VerifyFile(bucket, directory, filename, expectedSHA256 string) (bool, error)
 	RemoveBucket(bucket string) error
 	RemoveDirectory(bucket, directory string) error
 	RemoveFile(bucket, directory, fileName string) error
+	RestoreObject(bucket, directory, filename string, days int, tier string) error
+	IsRestored(bucket, directory, filename string) (bool, error)
+	SyncPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string, deleteExtra bool) (SyncResult, error)
+	DiffPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string) (onlyInSrc, onlyInDst, differing []string, err error)
+	GetETag(bucket, directory, filename string) (string, bool, error)
+	StatFiles(bucket string, keys []KeyRef) (map[string]minio.ObjectInfo, error)
+	GetUserMetadata(bucket, directory, filename string) (map[string]string, error)
+	GetLastModified(bucket, directory, filename string) (time.Time, bool, error)
+	SetBucketReplication(bucket string, config ReplicationConfig) error
+	GetBucketReplication(bucket string) (ReplicationConfig, error)
+	AddReplicationRule(bucket, id, prefix, destinationARN string) error
+	SetBucketEncryption(bucket, sseAlgorithm, kmsKeyID string) error
+	GetBucketEncryption(bucket string) (string, string, error)
+	SetBucketWebsite(bucket, indexDocument, errorDocument string) error
+	GetBucketWebsite(bucket string) (WebsiteConfig, error)
+	VerifyCredentials(ctx context.Context) error
+	BackendType() (string, error)
+	ListFileVersions(bucket, prefix string) ([]minio.ObjectInfo, error)
+	DeleteObjectVersion(bucket, directory, filename, versionID string) error
+	PruneVersions(bucket, prefix string, keep int) error
+	ListDeleteMarkers(bucket, prefix string) ([]minio.ObjectInfo, error)
+	PurgeDeleteMarkers(bucket, prefix string) (int, error)
+	SelectCSV(bucket, directory, filename, sqlExpression string) (io.ReadCloser, error)
+	GetFileResilient(bucket, directory, filename string) (io.ReadCloser, bool, error)
+	GetFileDecoded(bucket, directory, filename string) (io.ReadCloser, bool, error)
+	GetFileSniffed(bucket, directory, filename string) (io.ReadCloser, string, bool, error)
+	GetFileLines(bucket, directory, filename string) (*bufio.Scanner, func() error, error)
+	CreateFileCompressed(bucket, directory, fileName string, content io.Reader, mime string) error
+	CreateImageFile(bucket, directory, fileName string, content io.Reader, mime string) error
+	CreateFileDedup(bucket, directory string, content io.Reader, mime string) (string, bool, error)
+	BrowseDirectory(bucket, prefix string, sortBy string, ascending bool, offset, limit int) ([]string, []minio.ObjectInfo, int, error)
+	GenerateManifest(bucket, prefix string) ([]byte, error)
+	WriteManifest(bucket, prefix string) error
+	ListFilesAfter(bucket, prefix, startAfter string, recursive bool) ([]minio.ObjectInfo, error)
+	GlobFiles(bucket, pattern string) ([]string, error)
+	ListFilesModifiedSince(bucket, prefix string, since time.Time) ([]minio.ObjectInfo, error)
+	CopyFileWithTags(src, dst SourceRef, tags map[string]string, replaceTags bool) error
+	CloneFile(src, dst SourceRef) error
+	RenameFile(bucket, directory, oldName, newName string) error
+	ChangeStorageClass(bucket, directory, filename, storageClass string) error
+	TouchFile(bucket, directory, filename string) error
+	SetRedirect(bucket, directory, filename, target string) error
+	MakePrefixPublicRead(bucket, prefix string) error
+	UpdateBucketPolicy(bucket string, edit func(policy *BucketPolicyDoc) error) error
+	NewObjectReaderAt(bucket, directory, filename string) (io.ReaderAt, int64, error)
+	TrashFile(bucket, directory, filename string) error
+	RestoreFromTrash(bucket, originalDir, filename string) error
+	ResolveKey(directory, fileName string, uploaded time.Time) string
+	PresignedGetURLs(bucket string, keys []KeyRef, expiry time.Duration) (map[string]*url.URL, error)
+	UploadGrant(bucket, keyPrefix string, maxSize int64, allowedMimePrefix string, expiry time.Duration) (*UploadGrant, error)
+	RestrictPresignedSourceIP(bucket, prefix string, sourceIPs ...string) error
+	PresignedGetURLFromIP(bucket, directory, filename string, expiry time.Duration, sourceIP string) (*url.URL, error)
+	PresignedDownloadURL(bucket, directory, filename, downloadName string, expiry time.Duration) (*url.URL, error)
+	PresignedHeadURL(bucket, directory, filename string, expiry time.Duration) (*url.URL, error)
+	PresignedGetURLWithHeaders(bucket, directory, filename string, expiry time.Duration, respHeaders map[string]string) (*url.URL, error)
+	PresignedGetURLString(bucket, directory, filename string, expiry time.Duration) (string, error)
+	PublicURL(bucket, directory, filename string) (*url.URL, error)
+	PublicURLString(bucket, directory, filename string) (string, error)
+	DefaultBucket() string
+	ResolveBucket(alias string) (string, error)
+	CreateDirectoryDefault(name string) error
+	CreateFileDefault(directory, fileName string, content io.Reader, length int64, mime string, opts ...UploadOption) error
+	GetFileDefault(directory, filename string) (*minio.Object, error)
+	FileExistsDefault(directory, filename string) (bool, error)
+	RemoveDirectoryDefault(directory string) error
+	RemoveFileDefault(directory, fileName string) error
 }
 
 // Folder represents the folder structure in s3.
 type Folder struct {
 	Name    string
 	Folders map[string]*Folder
+
+	// FileCount is the number of the files directly in the folder, set only
+	// when the tree is listed WithFileCounts.
+	FileCount int
+}
+
+// FolderOption modifies the listing of the folder structure.
+type FolderOption func(opts *folderOptions)
+
+// folderOptions are the options of ListOfBucketFolder.
+type folderOptions struct {
+	fileCounts bool
+}
+
+// WithFileCounts sets the FileCount of the folders.
+func WithFileCounts() FolderOption {
+	return func(opts *folderOptions) {
+		opts.fileCounts = true
+	}
 }
 
 // Add adds a new sub folder to the parent folder.
@@ -78,11 +320,61 @@ func (f *Folder) Set(name string, keys ...string) {
 	f.Name = name
 }
 
+// BuildFolderTree builds the folder structure of the keys. Every part of a key
+// becomes a folder, including the file name, and the keys sharing a prefix
+// share the folders. Empty parts, like the one after a trailing slash, are
+// skipped.
+func BuildFolderTree(bucketName string, keys []string) *Folder {
+	root := &Folder{Name: bucketName}
+	for _, key := range keys {
+		folder := root
+		for _, elem := range strings.Split(key, "/") {
+			if elem == "" {
+				continue
+			}
+			if folder.Folders[elem] == nil {
+				folder.Add(elem, elem)
+			}
+			folder = folder.Folders[elem]
+		}
+	}
+	return root
+}
+
+// countFiles sets the FileCount of the folders of the tree built from the
+// keys. The keys with a trailing slash are folders, the duplicates are counted
+// once.
+func (f *Folder) countFiles(keys []string) {
+	counted := map[string]bool{}
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/") || counted[key] {
+			continue
+		}
+		counted[key] = true
+
+		path := []string{}
+		for _, elem := range strings.Split(key, "/") {
+			if elem != "" {
+				path = append(path, elem)
+			}
+		}
+		if len(path) == 0 {
+			continue
+		}
+		f.Get(path[:len(path)-1]...).FileCount++
+	}
+}
+
 // helper represents the S3 helper.
 type helper struct {
 	Enabled bool
 	Config  Config
 	Client  *minio.Client
+
+	cache     *existsCache
+	policies  *policyCache
+	skew      *clockSkew
+	transport http.RoundTripper
 }
 
 // New create a new S3 helper instance
@@ -93,66 +385,235 @@ func New(config Config) (Helper, error) {
 	}
 
 	s3 := helper{
-		Config:  config,
-		Enabled: false,
+		Config:    config,
+		Enabled:   false,
+		cache:     newExistsCache(config.ExistsCacheTTL),
+		policies:  newPolicyCache(),
+		transport: newTransport(config),
+	}
+	// The throttle is below the transports which retry, so the retried
+	// bodies are throttled too.
+	if config.MaxBytesPerSecond > 0 {
+		s3.transport = newThrottledTransport(s3.transport, config.MaxBytesPerSecond)
+	}
+	if config.AutoClockSkew {
+		s3.skew = newClockSkew()
+		s3.transport = newSkewTransport(s3.transport, s3.skew, config)
+	}
+	s3.transport = newRetryTransport(s3.transport, newRetryPolicy(config))
+	s3.transport = newHeaderTransport(s3.transport, config)
+	if config.ExpectedBucketOwner != "" {
+		s3.transport = newOwnerTransport(s3.transport, config)
 	}
 
-	s3.Client, err = minio.NewWithRegion(config.Endpoint, config.AccessKeyID, config.SecretAccessKey, config.SSL, config.Region)
+	s3.Client, err = newClient(config, s3.transport)
 	if err != nil {
-		return nil, errors.Wrap(err, "New minio.NewWithRegion")
+		return nil, err
 	}
 	s3.Enabled = true
+	if config.Tracer != nil {
+		return tracedHelper{helper: &s3, tracer: config.Tracer}, nil
+	}
 	return &s3, nil
 }
 
-// CreateBucket make new bucket on s3
+// newClient creates the minio client of the config, which sends the requests
+// with the transport.
+func newClient(config Config, transport http.RoundTripper) (*minio.Client, error) {
+	var client *minio.Client
+	var err error
+	if config.SignatureV2 {
+		creds := credentials.NewStaticV2(config.AccessKeyID, config.SecretAccessKey, "")
+		client, err = minio.NewWithCredentials(config.Endpoint, creds, config.SSL, config.Region)
+		if err != nil {
+			return nil, errors.Wrap(err, "New minio.NewWithCredentials")
+		}
+	} else {
+		client, err = minio.NewWithRegion(config.Endpoint, config.AccessKeyID, config.SecretAccessKey, config.SSL, config.Region)
+		if err != nil {
+			return nil, errors.Wrap(err, "New minio.NewWithRegion")
+		}
+	}
+	client.SetCustomTransport(transport)
+	return client, nil
+}
+
+// now returns the current time of the configured clock, corrected with the
+// detected clock skew of the server.
+func (s helper) now() time.Time {
+	return s.Config.now().Add(s.skew.get())
+}
+
+// now returns the current time of the configured clock.
+func (c Config) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
+
+// CreateBucket make new bucket on s3. A bucket which already exists and is
+// accessible with the credentials is not an error, so the call can be
+// repeated.
 func (s helper) CreateBucket(name string) error {
 	if !s.Enabled {
-		return errors.New("server is not enabled")
+		return ErrServerDisabled
 	}
 
-	return s.Client.MakeBucket(name, s.Config.Region)
+	err := s.Client.MakeBucket(name, s.Config.Region)
+	if err != nil && !s.ownBucketExists(name, err) {
+		return err
+	}
+
+	s.cache.set(name, true)
+	return nil
+}
+
+// ownBucketExists returns true if the MakeBucket error reports a bucket which
+// already exists and is owned by the credentials.
+func (s helper) ownBucketExists(name string, err error) bool {
+	resp, ok := err.(minio.ErrorResponse)
+	if !ok {
+		return false
+	}
+
+	switch resp.Code {
+	case "BucketAlreadyOwnedByYou":
+		return true
+	case "BucketAlreadyExists":
+		// Some servers report the own buckets like this too, the buckets of
+		// others can not be accessed.
+		exists, err := s.Client.BucketExists(name)
+		return err == nil && exists
+	}
+	return false
 }
 
 // CreateDirectory make new directory in a bucket
 func (s helper) CreateDirectory(bucket, name string) error {
 	if !s.Enabled {
-		return errors.New("server is not enabled")
+		return ErrServerDisabled
 	}
 
 	opts := minio.PutObjectOptions{
-		ContentType: "plain/text",
+		ContentType: s.directoryMarkerContentType(),
+	}
+	reader := strings.NewReader(s.now().String())
+
+	return s.putObject(bucket, name+"/"+directoryMarker, reader, int64(reader.Len()), opts)
+}
+
+// CreateFile make new file in specific directory in a specific bucket
+func (s helper) CreateFile(bucket, directory, fileName string, content io.Reader, length int64, mime string, options ...UploadOption) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	opts := uploadOptions{
+		PutObjectOptions: minio.PutObjectOptions{
+			ContentType: mime,
+		},
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return s.putObjectWithContext(opts.context(context.Background()), bucket, s.uploadKey(directory, fileName), content, length, opts.PutObjectOptions)
+}
+
+// CreateFileWithContext is CreateFile which stops the upload when the context
+// is cancelled, even in the middle of a request. A multipart upload is
+// aborted, so no parts are left on the server, the error is returned as
+// AbortedUploadError which reports whether the abort succeeded.
+func (s helper) CreateFileWithContext(ctx context.Context, bucket, directory, fileName string, content io.Reader, length int64, mime string, options ...UploadOption) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	opts := uploadOptions{
+		PutObjectOptions: minio.PutObjectOptions{
+			ContentType: mime,
+		},
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return s.putObjectWithContext(opts.context(ctx), bucket, s.uploadKey(directory, fileName), content, length, opts.PutObjectOptions)
+}
+
+// PutObject uploads the content to the key with the given minio options, for
+// the options which have no dedicated method. The key is cleaned like the
+// keys joined from a directory and a file name, the key template is not
+// applied.
+func (s helper) PutObject(bucket, key string, content io.Reader, length int64, opts minio.PutObjectOptions) error {
+	if !s.Enabled {
+		return ErrServerDisabled
 	}
-	reader := strings.NewReader(time.Now().String())
 
-	_, err := s.Client.PutObject(bucket, name+"/.created", reader, int64(reader.Len()), opts)
+	key, err := cleanKey(key)
 	if err != nil {
 		return err
 	}
 
-	return err
+	return s.putObject(bucket, key, content, length, opts)
 }
 
-// CreateFile make new file in specific directory in a specific bucket
-func (s helper) CreateFile(bucket, directory, fileName string, content io.Reader, length int64, mime string) error {
-	if !s.Enabled {
-		return errors.New("server is not enabled")
+// cleanKey cleans the key like the keys joined from a directory and a file
+// name.
+func cleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(filepath.Join("/", key), "/")
+	if key == "" {
+		return "", errors.New("empty key")
 	}
+	return key, nil
+}
 
-	opts := minio.PutObjectOptions{
-		ContentType: mime,
+// putObject uploads the object and records its existence. The content of
+// unknown length, or larger than the configured part size, is uploaded in
+// parts.
+func (s helper) putObject(bucket, key string, content io.Reader, length int64, opts minio.PutObjectOptions) error {
+	return s.putObjectWithContext(context.Background(), bucket, key, content, length, opts)
+}
+
+// putObjectWithContext is putObject which stops the upload when the context
+// is cancelled.
+func (s helper) putObjectWithContext(ctx context.Context, bucket, key string, content io.Reader, length int64, opts minio.PutObjectOptions) error {
+	partSize := int64(s.Config.UploadPartSize)
+
+	var err error
+	if length < 0 && partSize == 0 {
+		err = s.putObjectMultipart(ctx, bucket, key, content, streamUploadPartSize, opts)
+	} else if partSize > 0 && (length < 0 || length > partSize) {
+		err = s.putObjectMultipart(ctx, bucket, key, content, scalePartSize(partSize, length), opts)
+	} else {
+		_, err = s.Client.PutObjectWithContext(ctx, bucket, key, content, length, opts)
+	}
+	if err != nil {
+		return err
 	}
 
-	_, err := s.Client.PutObject(bucket, directory+"/"+fileName, content, length, opts)
+	s.cache.set(fileCacheKey(bucket, key), true)
+	return nil
+}
+
+// removeObject removes the object and records its absence.
+func (s helper) removeObject(bucket, key string) error {
+	err := s.Client.RemoveObject(bucket, key)
 	if err != nil {
 		return err
 	}
 
-	return err
+	s.cache.set(fileCacheKey(bucket, key), false)
+	return nil
 }
 
 // GetFile returns the
 func (s helper) GetFile(bucket, directory, filename string) (*minio.Object, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
 	obj, err := s.Client.GetObject(
 		bucket,
 		filepath.Join(directory, filename),
@@ -171,18 +632,56 @@ func (s helper) GetFile(bucket, directory, filename string) (*minio.Object, erro
 	return obj, nil
 }
 
+// GetObjectRaw returns the object with the given minio options, like a range,
+// conditions or the SSE-C key, for the options which have no dedicated method.
+// The key is cleaned like in PutObject. The found flag is false if the object
+// does not exist. The caller must close the object.
+func (s helper) GetObjectRaw(bucket, key string, opts minio.GetObjectOptions) (*minio.Object, bool, error) {
+	if !s.Enabled {
+		return nil, false, ErrServerDisabled
+	}
+
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The object is checked with a separate request, as minio drops the
+	// range of the object once it is statted.
+	_, err = s.Client.StatObject(bucket, key, minio.StatObjectOptions{GetObjectOptions: opts})
+	if err, ok := err.(minio.ErrorResponse); ok && (err.Code == "NoSuchKey") {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "StatObject failed")
+	}
+
+	obj, err := s.Client.GetObject(bucket, key, opts)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "GetObject failed")
+	}
+
+	return obj, true, nil
+}
+
 // FileExists returns the file exists or not.
 func (s helper) FileExists(bucket, directory, filename string) (bool, error) {
+	if !s.Enabled {
+		return false, ErrServerDisabled
+	}
+
+	key := fileCacheKey(bucket, filepath.Join(directory, filename))
+	if exists, ok := s.cache.get(key); ok {
+		return exists, nil
+	}
+
 	obj, err := s.GetFile(bucket, directory, filename)
 	if err != nil {
 		return false, err
 	}
 
-	if obj == nil {
-		return false, nil
-	}
-
-	return true, nil
+	s.cache.set(key, obj != nil)
+	return obj != nil, nil
 }
 
 // GetS3Host returns S3 host.
@@ -193,23 +692,44 @@ func (s helper) GetS3Host() string {
 // BucketExists checks the bucket exists or not.
 func (s helper) BucketExists(bucket string) (bool, error) {
 	if !s.Enabled {
-		return false, errors.New("server is not enabled")
+		return false, ErrServerDisabled
+	}
+
+	if exists, ok := s.cache.get(bucket); ok {
+		return exists, nil
 	}
 
 	exists, err := s.Client.BucketExists(bucket)
 	if err, ok := err.(minio.ErrorResponse); ok && (err.Code == "NoSuchBucket") {
+		s.cache.set(bucket, false)
 		return false, nil
 	}
 	if err != nil {
 		return false, errors.Wrap(err, "BucketExists failed")
 	}
+
+	s.cache.set(bucket, exists)
 	return exists, nil
 }
 
+// MustBucketExist returns ErrNoSuchBucket if the bucket does not exist, or the
+// error if it could not be determined.
+func (s helper) MustBucketExist(bucket string) error {
+	exists, err := s.BucketExists(bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNoSuchBucket
+	}
+
+	return nil
+}
+
 // ListOfBucket lists the buckets.
 func (s helper) ListOfBucket() ([]string, error) {
 	if !s.Enabled {
-		return nil, nil
+		return nil, ErrServerDisabled
 	}
 
 	binfos, err := s.Client.ListBuckets()
@@ -225,35 +745,79 @@ func (s helper) ListOfBucket() ([]string, error) {
 	return ret, nil
 }
 
-// ListOfBucketFolder lists the buckets folders.
-func (s helper) ListOfBucketFolder(bucketName string, isRecursive bool) (*Folder, error) {
+// ListBucketsDetailed lists the buckets with their creation dates.
+func (s helper) ListBucketsDetailed() ([]minio.BucketInfo, error) {
 	if !s.Enabled {
-		return nil, nil
+		return nil, ErrServerDisabled
 	}
 
-	root := &Folder{Name: bucketName}
+	binfos, err := s.Client.ListBuckets()
+	if err != nil {
+		return nil, errors.Wrap(err, "list failed")
+	}
 
+	return binfos, nil
+}
+
+// ListOfBucketFolder lists the buckets folders.
+func (s helper) ListOfBucketFolder(bucketName string, isRecursive bool, options ...FolderOption) (*Folder, error) {
+	return s.ListOfBucketFolderWithContext(context.Background(), bucketName, isRecursive, options...)
+}
+
+// ListOfBucketFolderWithContext is ListOfBucketFolder which stops listing
+// and returns the error of the context when it is cancelled.
+func (s helper) ListOfBucketFolderWithContext(ctx context.Context, bucketName string, isRecursive bool, options ...FolderOption) (*Folder, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	// The done channel stops the listing goroutine of minio, it is closed
+	// when the context is cancelled or the listing returns.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	doneCh := make(chan struct{})
-	defer close(doneCh)
+	go func() {
+		<-ctx.Done()
+		close(doneCh)
+	}()
+
+	keys := []string{}
+	objects := s.Client.ListObjectsV2(bucketName, "", isRecursive, doneCh)
+	defer func() {
+		// The goroutine sends a listing error without checking the done
+		// channel, so the results are drained until it closes the channel.
+		go func() {
+			for range objects {
+			}
+		}()
+	}()
+	for {
+		var obj minio.ObjectInfo
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case obj, ok = <-objects:
+		}
+		if !ok {
+			break
+		}
 
-	objinfo := s.Client.ListObjectsV2(bucketName, "", isRecursive, doneCh)
-	for obj := range objinfo {
 		if obj.Err != nil {
 			return nil, errors.Wrap(obj.Err, "list object error")
 		}
+		keys = append(keys, obj.Key)
+	}
 
-		path := strings.Split(obj.Key, "/")
-		for i, elem := range path {
-			if len(path) == 1 && root.Get(elem) == nil {
-				root.Add(elem, elem)
-				continue
-			}
-
-			parent := root.Get(path[0:i]...)
-			parent.Add(elem, elem)
-		}
+	opts := folderOptions{}
+	for _, option := range options {
+		option(&opts)
 	}
 
+	root := BuildFolderTree(bucketName, keys)
+	if opts.fileCounts {
+		root.countFiles(keys)
+	}
 	return root, nil
 }
 
@@ -264,28 +828,34 @@ func (s helper) GetBucketName() string {
 
 // RemoveBucket removes the given bucket.
 func (s helper) RemoveBucket(bucket string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
 	err := s.Client.RemoveBucket(bucket)
 	if err != nil {
 		return err
 	}
+
+	s.cache.removePrefix(bucket + "/")
+	s.cache.set(bucket, false)
 	return nil
 }
 
 // RemoveDirectory removes the given directory.
 func (s helper) RemoveDirectory(bucket, directory string) error {
-	err := s.Client.RemoveObject(bucket, directory)
-	if err != nil {
-		return err
+	if !s.Enabled {
+		return ErrServerDisabled
 	}
 
-	return nil
+	return s.removeObject(bucket, directory)
 }
 
 // RemoveFiles removes the given file from directory.
 func (s helper) RemoveFile(bucket, directory, fileName string) error {
-	err := s.Client.RemoveObject(bucket, directory+"/"+fileName)
-	if err != nil {
-		return err
+	if !s.Enabled {
+		return ErrServerDisabled
 	}
-	return nil
+
+	return s.removeObject(bucket, directory+"/"+fileName)
 }
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -102,6 +103,28 @@ func TestHelper(t *testing.T) {
 			So(err, ShouldNotBeNil)
 		})
 
+		Convey("Already exists", func() {
+			bucketError := func(code string, headStatus int) error {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Method == "HEAD" {
+						w.WriteHeader(headStatus)
+						return
+					}
+					w.WriteHeader(http.StatusConflict)
+					fmt.Fprintf(w, "<Error><Code>%s</Code></Error>", code)
+				}))
+				defer server.Close()
+
+				s3 := newTestHelper(server)
+				return s3.CreateBucket("x43563")
+			}
+
+			So(bucketError("BucketAlreadyOwnedByYou", http.StatusForbidden), ShouldBeNil)
+			So(bucketError("BucketAlreadyExists", http.StatusOK), ShouldBeNil)
+			So(bucketError("BucketAlreadyExists", http.StatusForbidden), ShouldNotBeNil)
+			So(bucketError("InvalidBucketName", http.StatusOK), ShouldNotBeNil)
+		})
+
 	})
 
 	Convey("CreateDirectory", t, func() {
@@ -298,5 +321,97 @@ func TestHelper(t *testing.T) {
 			So(err, ShouldNotBeNil)
 			So(res, ShouldBeFalse)
 		})
+		Convey("Missing", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			res, err := s3.BucketExists("x43563")
+			So(err, ShouldBeNil)
+			So(res, ShouldBeFalse)
+		})
+	})
+
+	Convey("MustBucketExist", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.MustBucketExist("x43563")
+			So(err, ShouldNotBeNil)
+			So(err, ShouldNotEqual, ErrNoSuchBucket)
+		})
+		Convey("Exists", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.MustBucketExist("x43563")
+			So(err, ShouldBeNil)
+		})
+		Convey("Missing", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.MustBucketExist("x43563")
+			So(err, ShouldEqual, ErrNoSuchBucket)
+		})
+		Convey("Error", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.MustBucketExist("x43563")
+			So(err, ShouldNotBeNil)
+			So(err, ShouldNotEqual, ErrNoSuchBucket)
+		})
+	})
+
+	Convey("ListBucketsDetailed", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<ListAllMyBucketsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Owner><ID>owner</ID><DisplayName>owner</DisplayName></Owner>
+  <Buckets>
+    <Bucket><Name>x43563</Name><CreationDate>2018-06-01T12:00:00.000Z</CreationDate></Bucket>
+    <Bucket><Name>x43564</Name><CreationDate>2018-07-01T12:00:00.000Z</CreationDate></Bucket>
+  </Buckets>
+</ListAllMyBucketsResult>`))
+		}))
+		defer server.Close()
+
+		s3 := newTestHelper(server)
+		buckets, err := s3.ListBucketsDetailed()
+		So(err, ShouldBeNil)
+		So(buckets, ShouldHaveLength, 2)
+		So(buckets[0].Name, ShouldEqual, "x43563")
+		So(buckets[0].CreationDate.Equal(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)), ShouldBeTrue)
+		So(buckets[1].Name, ShouldEqual, "x43564")
 	})
 }
+
+// testConfig returns a config which points to the given test server.
+func testConfig(server *httptest.Server) Config {
+	return Config{
+		AccessKeyID:     "x",
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		Region:          "x",
+		SecretAccessKey: "x",
+		BucketName:      "x",
+		SSL:             false,
+	}
+}
+
+// newTestHelper creates a helper which talks to the given test server.
+func newTestHelper(server *httptest.Server) Helper {
+	s3, err := New(testConfig(server))
+	So(err, ShouldBeNil)
+	return s3
+}
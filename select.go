@@ -0,0 +1,49 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// SelectCSV runs the SQL expression on the CSV file with a header row and
+// returns the matching records as CSV. The caller must close the reader.
+func (s helper) SelectCSV(bucket, directory, filename, sqlExpression string) (io.ReadCloser, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	opts := minio.SelectObjectOptions{
+		Expression:     sqlExpression,
+		ExpressionType: minio.QueryExpressionTypeSQL,
+		InputSerialization: minio.SelectObjectInputSerialization{
+			CompressionType: minio.SelectCompressionNONE,
+			CSV: &minio.CSVInputOptions{
+				FileHeaderInfo:       minio.CSVFileHeaderInfoUse,
+				RecordDelimiter:      "\n",
+				FieldDelimiter:       ",",
+				QuoteCharacter:       `"`,
+				QuoteEscapeCharacter: `"`,
+			},
+		},
+		OutputSerialization: minio.SelectObjectOutputSerialization{
+			CSV: &minio.CSVOutputOptions{
+				QuoteFields:          minio.CSVQuoteFieldsAsNeeded,
+				RecordDelimiter:      "\n",
+				FieldDelimiter:       ",",
+				QuoteCharacter:       `"`,
+				QuoteEscapeCharacter: `"`,
+			},
+		},
+	}
+
+	results, err := s.Client.SelectObjectContent(context.Background(), bucket, filepath.Join(directory, filename), opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "SelectObjectContent failed")
+	}
+
+	return results, nil
+}
@@ -0,0 +1,90 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// selectMessage encodes an event stream message of the select response.
+func selectMessage(eventType string, payload []byte) []byte {
+	headers := &bytes.Buffer{}
+	for _, header := range [][2]string{{":message-type", "event"}, {":event-type", eventType}} {
+		headers.WriteByte(byte(len(header[0])))
+		headers.WriteString(header[0])
+		headers.WriteByte(7)
+		binary.Write(headers, binary.BigEndian, uint16(len(header[1])))
+		headers.WriteString(header[1])
+	}
+
+	msg := &bytes.Buffer{}
+	binary.Write(msg, binary.BigEndian, uint32(16+headers.Len()+len(payload)))
+	binary.Write(msg, binary.BigEndian, uint32(headers.Len()))
+	binary.Write(msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(headers.Bytes())
+	msg.Write(payload)
+	binary.Write(msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+
+	return msg.Bytes()
+}
+
+func TestSelect(t *testing.T) {
+	Convey("SelectCSV", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.SelectCSV("x43563", "dir", "file.csv", "SELECT * FROM S3Object")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var path, body string
+			var query map[string][]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				query = r.URL.Query()
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+
+				w.Write(selectMessage("Records", []byte("1\n2\n")))
+				w.Write(selectMessage("End", nil))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			results, err := s3.SelectCSV("x43563", "dir", "file.csv", "SELECT s._1 FROM S3Object s")
+			So(err, ShouldBeNil)
+			defer results.Close()
+
+			records, err := ioutil.ReadAll(results)
+			So(err, ShouldBeNil)
+			So(string(records), ShouldEqual, "1\n2\n")
+
+			So(path, ShouldEqual, "/x43563/dir/file.csv")
+			So(query, ShouldContainKey, "select")
+			So(body, ShouldContainSubstring, "<Expression>SELECT s._1 FROM S3Object s</Expression>")
+			So(body, ShouldContainSubstring, "<FileHeaderInfo>USE</FileHeaderInfo>")
+			So(body, ShouldContainSubstring, "<Comments></Comments>")
+		})
+
+		Convey("Error", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("<Error><Code>InvalidQuery</Code></Error>"))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			_, err := s3.SelectCSV("x43563", "dir", "file.csv", "SELEC")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
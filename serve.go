@@ -0,0 +1,39 @@
+package s3
+
+import (
+	"net/http"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+)
+
+// ServeFile writes the file to the response with its Content-Type, ETag and
+// Last-Modified headers. The Range, If-None-Match and If-Modified-Since
+// headers of the request are honored, only the requested part of the file is
+// downloaded. A missing file is answered with 404. The returned error is set
+// only if nothing was written to the response.
+func (s helper) ServeFile(w http.ResponseWriter, r *http.Request, bucket, directory, filename string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	obj, info, found, err := s.openObject(bucket, filepath.Join(directory, filename), minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	if !found {
+		http.NotFound(w, r)
+		return nil
+	}
+	defer obj.Close()
+
+	if info.ContentType != "" {
+		w.Header().Set("Content-Type", info.ContentType)
+	}
+	if info.ETag != "" {
+		w.Header().Set("ETag", `"`+info.ETag+`"`)
+	}
+
+	http.ServeContent(w, r, filename, info.LastModified, obj)
+	return nil
+}
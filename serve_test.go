@@ -0,0 +1,70 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestServeFile(t *testing.T) {
+	Convey("ServeFile", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("0123456789"), http.Header{"Content-Type": {"text/plain"}})
+		obj, _ := server.get("x43563", "dir/file.txt")
+
+		s3 := newTestHelper(server.Server)
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			w := httptest.NewRecorder()
+			err := s3.ServeFile(w, httptest.NewRequest("GET", "/", nil), "x43563", "dir", "file.txt")
+			So(err, ShouldEqual, ErrServerDisabled)
+		})
+
+		Convey("Full", func() {
+			w := httptest.NewRecorder()
+			err := s3.ServeFile(w, httptest.NewRequest("GET", "/", nil), "x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(w.Body.String(), ShouldEqual, "0123456789")
+			So(w.Header().Get("Content-Type"), ShouldEqual, "text/plain")
+			So(w.Header().Get("Content-Length"), ShouldEqual, "10")
+			So(w.Header().Get("ETag"), ShouldEqual, `"`+obj.etag+`"`)
+			So(w.Header().Get("Last-Modified"), ShouldEqual, obj.lastModified.Format(http.TimeFormat))
+		})
+
+		Convey("Range", func() {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Range", "bytes=2-5")
+			w := httptest.NewRecorder()
+			err := s3.ServeFile(w, r, "x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusPartialContent)
+			So(w.Body.String(), ShouldEqual, "2345")
+			So(w.Header().Get("Content-Range"), ShouldEqual, "bytes 2-5/10")
+		})
+
+		Convey("Not modified", func() {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("If-None-Match", `"`+obj.etag+`"`)
+			w := httptest.NewRecorder()
+			err := s3.ServeFile(w, r, "x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusNotModified)
+			So(w.Body.Len(), ShouldEqual, 0)
+		})
+
+		Convey("Not found", func() {
+			w := httptest.NewRecorder()
+			err := s3.ServeFile(w, httptest.NewRequest("GET", "/", nil), "x43563", "dir", "missing.txt")
+			So(err, ShouldBeNil)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+}
@@ -0,0 +1,305 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/s3utils"
+	"github.com/pkg/errors"
+)
+
+// maxPresignExpiry is the longest expiry of the presigned URLs.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// clockSkew records the offset of the server clock from the local clock. A
+// nil value records nothing.
+type clockSkew struct {
+	// checkMu serializes the clock checks, so the server is asked once.
+	checkMu sync.Mutex
+	checked bool
+
+	mu     sync.Mutex
+	offset time.Duration
+}
+
+// newClockSkew creates a new clock skew record.
+func newClockSkew() *clockSkew {
+	return &clockSkew{}
+}
+
+// get returns the offset of the server clock.
+func (c *clockSkew) get() time.Duration {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// set records the offset of the server clock.
+func (c *clockSkew) set(offset time.Duration) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = offset
+}
+
+// validateAutoClockSkew checks that the clock skew is corrected only with the
+// signature version 4, the presigned URLs of the version 2 are signed by minio.
+func (c Config) validateAutoClockSkew(value interface{}) error {
+	if value.(bool) && c.SignatureV2 {
+		return errors.New("can not be used with signature_v2")
+	}
+	return nil
+}
+
+// signedHeadersRegexp extracts the signed headers of a signature v4
+// Authorization header.
+var signedHeadersRegexp = regexp.MustCompile(`SignedHeaders=([^,]*)`)
+
+// skewTransport learns the clock skew from the RequestTimeTooSkewed errors,
+// comparing the Date header of the response with the local clock. The failed
+// request is signed again with the clock of the server and retried once, the
+// later requests are signed with it before they are sent. The chunk signed
+// uploads, which minio sends to the endpoints without SSL, and the requests
+// whose body can not be read again are not retried.
+type skewTransport struct {
+	transport       http.RoundTripper
+	skew            *clockSkew
+	clock           func() time.Time
+	accessKeyID     string
+	secretAccessKey string
+}
+
+// newSkewTransport wraps the transport to record the clock skew.
+func newSkewTransport(transport http.RoundTripper, skew *clockSkew, config Config) *skewTransport {
+	return &skewTransport{
+		transport:       transport,
+		skew:            skew,
+		clock:           config.now,
+		accessKeyID:     config.AccessKeyID,
+		secretAccessKey: config.SecretAccessKey,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *skewTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if offset := t.skew.get(); offset != 0 {
+		if signed, ok := t.sign(req, t.clock().Add(offset)); ok {
+			req = signed
+		}
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusForbidden {
+		return resp, err
+	}
+
+	errResp, ok := responseError(resp).(minio.ErrorResponse)
+	if !ok || errResp.Code != "RequestTimeTooSkewed" {
+		return resp, nil
+	}
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return resp, nil
+	}
+	t.skew.set(date.Sub(t.clock()))
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	retried, ok := t.sign(req, date)
+	if !ok {
+		return resp, nil
+	}
+	if req.GetBody != nil {
+		retried.Body, err = req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+	}
+
+	resp.Body.Close()
+	return t.transport.RoundTrip(retried)
+}
+
+// sign returns the copy of the request signed with the signature version 4 at
+// the given time, with the signed headers of its signature, like minio does
+// at the local time. It fails for the requests which are not signed with the
+// version 4 and the chunk signed uploads.
+func (t *skewTransport) sign(req *http.Request, at time.Time) (*http.Request, bool) {
+	authorization := req.Header.Get("Authorization")
+	credential := credentialRegexp.FindStringSubmatch(authorization)
+	signedHeaders := signedHeadersRegexp.FindStringSubmatch(authorization)
+	if credential == nil || signedHeaders == nil || req.Header.Get("X-Amz-Content-Sha256") == streamingPayload {
+		return nil, false
+	}
+
+	at = at.UTC()
+	signed := *req
+	signed.Header = http.Header{}
+	for k, v := range req.Header {
+		signed.Header[k] = v
+	}
+	signed.Header.Set("X-Amz-Date", at.Format(iso8601DateFormat))
+
+	var headers bytes.Buffer
+	for _, name := range strings.Split(signedHeaders[1], ";") {
+		values := signed.Header[http.CanonicalHeaderKey(name)]
+		if name == "host" {
+			values = []string{signed.Host}
+			if signed.Host == "" {
+				values = []string{signed.URL.Host}
+			}
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers.WriteString(name + ":" + strings.Join(trimmed, ",") + "\n")
+	}
+
+	payload := signed.Header.Get("X-Amz-Content-Sha256")
+	if payload == "" {
+		payload = "UNSIGNED-PAYLOAD"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		signed.Method,
+		s3utils.EncodePath(signed.URL.Path),
+		strings.Replace(signed.URL.Query().Encode(), "+", "%20", -1),
+		headers.String(),
+		signedHeaders[1],
+		payload,
+	}, "\n")
+	region := credential[1]
+	scope := strings.Join([]string{at.Format("20060102"), region, "s3", "aws4_request"}, "/")
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + at.Format(iso8601DateFormat) + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+	signature := hex.EncodeToString(sumHMAC(signingKeyV4(t.secretAccessKey, region, at), stringToSign))
+
+	signed.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+t.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders[1]+", Signature="+signature)
+	return &signed, true
+}
+
+// checkClockSkew sends a request to the bucket once, so a skewed clock is
+// detected before the first presigned URL is signed.
+func (s helper) checkClockSkew(bucket string) {
+	if s.skew == nil {
+		return
+	}
+
+	s.skew.checkMu.Lock()
+	defer s.skew.checkMu.Unlock()
+	if s.skew.checked {
+		return
+	}
+
+	resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"location": {""}},
+	})
+	if _, ok := err.(minio.ErrorResponse); ok || err == nil {
+		// The server answered, the skew is known.
+		s.skew.checked = true
+	}
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// presignObject returns a presigned GET or HEAD URL of the object. With
+// AutoClockSkew the URL is signed with the clock of the server.
+func (s helper) presignObject(method, bucket, key string, expiry time.Duration, params url.Values) (*url.URL, error) {
+	if s.skew == nil {
+		if method == "HEAD" {
+			return s.Client.PresignedHeadObject(bucket, key, expiry, params)
+		}
+		return s.Client.PresignedGetObject(bucket, key, expiry, params)
+	}
+
+	if expiry < time.Second || expiry > maxPresignExpiry {
+		return nil, errors.Errorf("invalid expiry: %s", expiry)
+	}
+	if err := s3utils.CheckValidBucketName(bucket); err != nil {
+		return nil, err
+	}
+	if err := s3utils.CheckValidObjectName(key); err != nil {
+		return nil, err
+	}
+
+	s.checkClockSkew(bucket)
+	return s.presignV4(method, bucket, key, expiry, params, s.now()), nil
+}
+
+// presignV4 presigns the request with the signature version 4 at the given
+// time, like minio does at the local time.
+func (s helper) presignV4(method, bucket, key string, expiry time.Duration, params url.Values, t time.Time) *url.URL {
+	u := s.objectURL(bucket, key)
+
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	if s.Config.AccessKeyID == "" || s.Config.SecretAccessKey == "" {
+		u.RawQuery = query.Encode()
+		return u
+	}
+
+	t = t.UTC()
+	scope := strings.Join([]string{t.Format("20060102"), s.Config.Region, "s3", "aws4_request"}, "/")
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Date", t.Format(iso8601DateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiry/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	query.Set("X-Amz-Credential", s.Config.AccessKeyID+"/"+scope)
+	rawQuery := strings.Replace(query.Encode(), "+", "%20", -1)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		s3utils.EncodePath(u.Path),
+		rawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + t.Format(iso8601DateFormat) + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	signingKey := signingKeyV4(s.Config.SecretAccessKey, s.Config.Region, t)
+	u.RawQuery = rawQuery + "&X-Amz-Signature=" + hex.EncodeToString(sumHMAC(signingKey, stringToSign))
+	return u
+}
+
+// signingKeyV4 returns the signature version 4 signing key of the day.
+func signingKeyV4(secretAccessKey, region string, t time.Time) []byte {
+	signingKey := []byte("AWS4" + secretAccessKey)
+	for _, part := range []string{t.Format("20060102"), region, "s3", "aws4_request"} {
+		signingKey = sumHMAC(signingKey, part)
+	}
+	return signingKey
+}
+
+// sumHMAC returns the HMAC-SHA256 of the data.
+func sumHMAC(key []byte, data string) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write([]byte(data))
+	return hash.Sum(nil)
+}
@@ -0,0 +1,140 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/pkg/s3signer"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClockSkew(t *testing.T) {
+	Convey("AutoClockSkew", t, func() {
+		Convey("Signature version 2", func() {
+			config := Config{
+				AccessKeyID:     "x",
+				Endpoint:        "localhost",
+				Region:          "x",
+				SecretAccessKey: "x",
+				BucketName:      "x",
+				SignatureV2:     true,
+				AutoClockSkew:   true,
+			}
+			So(config.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("Same signature as minio", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+			s3, err := New(testConfig(server))
+			So(err, ShouldBeNil)
+			h := s3.(*helper)
+
+			params := url.Values{"response-content-type": {"text/plain; charset=utf-8"}}
+			for i := 0; i < 3; i++ {
+				now := time.Now()
+				expected, err := h.Client.PresignedGetObject("x43563", "dir/file name.txt", time.Hour, params)
+				So(err, ShouldBeNil)
+				if expected.Query().Get("X-Amz-Date") != now.UTC().Format(iso8601DateFormat) {
+					// The second changed in between.
+					continue
+				}
+
+				u := h.presignV4("GET", "x43563", "dir/file name.txt", time.Hour, params, now)
+				So(u.String(), ShouldEqual, expected.String())
+				return
+			}
+			t.Fatal("clock kept changing")
+		})
+
+		Convey("Skewed clock", func() {
+			serverTime := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+			checks := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				checks++
+				date, err := time.Parse(iso8601DateFormat, r.Header.Get("X-Amz-Date"))
+				if err != nil || serverTime.Sub(date) > 15*time.Minute {
+					w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte("<Error><Code>RequestTimeTooSkewed</Code></Error>"))
+					return
+				}
+				w.Write([]byte("<LocationConstraint></LocationConstraint>"))
+			}))
+			defer server.Close()
+
+			config := testConfig(server)
+			config.AutoClockSkew = true
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			u, err := s3.PresignedGetURLWithHeaders("x43563", "dir", "file.txt", time.Hour, nil)
+			So(err, ShouldBeNil)
+			date, err := time.Parse(iso8601DateFormat, u.Query().Get("X-Amz-Date"))
+			So(err, ShouldBeNil)
+			So(date, ShouldHappenWithin, 5*time.Second, serverTime)
+			So(u.Query().Get("X-Amz-Signature"), ShouldNotBeEmpty)
+
+			_, err = s3.PresignedGetURLWithHeaders("x43563", "dir", "file.txt", time.Hour, nil)
+			So(err, ShouldBeNil)
+			// The check is retried with the clock of the server.
+			So(checks, ShouldEqual, 2)
+		})
+
+		Convey("Retried request", func() {
+			serverTime := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				date, err := time.Parse(iso8601DateFormat, r.Header.Get("X-Amz-Date"))
+				if err != nil || serverTime.Sub(date) > 15*time.Minute {
+					w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte("<Error><Code>RequestTimeTooSkewed</Code></Error>"))
+					return
+				}
+				w.Write([]byte(`<ObjectLockConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`))
+			}))
+			defer server.Close()
+
+			config := testConfig(server)
+			config.AutoClockSkew = true
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			enabled, _, _, _, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldBeNil)
+			So(enabled, ShouldBeTrue)
+			So(requests, ShouldEqual, 2)
+
+			// The skew is known, the request is signed with it at once.
+			_, _, _, _, err = s3.GetObjectLockConfig("x43563")
+			So(err, ShouldBeNil)
+			So(requests, ShouldEqual, 3)
+		})
+
+		Convey("Same request signature as minio", func() {
+			transport := newSkewTransport(nil, newClockSkew(), Config{AccessKeyID: "key", SecretAccessKey: "secret"})
+
+			req, err := http.NewRequest("PUT", "http://localhost:9000/x43563/dir/file%20name.txt?tagging=&versionId=a+b", nil)
+			So(err, ShouldBeNil)
+			req.Header.Set("X-Amz-Content-Sha256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+			req.Header.Set("X-Amz-Meta-Owner", "alice")
+			req.Header.Set("Content-Type", "text/plain")
+			expected := s3signer.SignV4(*req, "key", "secret", "", "us-east-1")
+
+			date, err := time.Parse(iso8601DateFormat, expected.Header.Get("X-Amz-Date"))
+			So(err, ShouldBeNil)
+			signed, ok := transport.sign(expected, date)
+			So(ok, ShouldBeTrue)
+			So(signed.Header.Get("Authorization"), ShouldEqual, expected.Header.Get("Authorization"))
+
+			expected.Header.Set("X-Amz-Content-Sha256", streamingPayload)
+			_, ok = transport.sign(expected, date)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// sniffLen is the number of bytes used to detect the content type.
+const sniffLen = 512
+
+// sniffedReader reads the sniffed bytes and the rest of the object.
+type sniffedReader struct {
+	io.Reader
+	io.Closer
+}
+
+// GetFileSniffed returns the content of the file with the content type
+// detected from its first 512 bytes, ignoring the stored content type. The
+// found flag is false if the file does not exist. The caller must close the
+// reader.
+func (s helper) GetFileSniffed(bucket, directory, filename string) (io.ReadCloser, string, bool, error) {
+	if !s.Enabled {
+		return nil, "", false, ErrServerDisabled
+	}
+
+	obj, _, found, err := s.openObject(bucket, filepath.Join(directory, filename), minio.GetObjectOptions{})
+	if err != nil || !found {
+		return nil, "", false, err
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(obj, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		obj.Close()
+		return nil, "", false, errors.Wrap(err, "read failed")
+	}
+	head = head[:n]
+
+	reader := sniffedReader{
+		Reader: io.MultiReader(bytes.NewReader(head), obj),
+		Closer: obj,
+	}
+	return reader, http.DetectContentType(head), true, nil
+}
@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSniff(t *testing.T) {
+	Convey("GetFileSniffed", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, _, err := s3.GetFileSniffed("x43563", "dir", "file.png")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Wrong stored type", func() {
+			content := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 1000)...)
+			server.put("x43563", "dir/file.png", content, http.Header{"Content-Type": {"text/plain"}})
+
+			reader, contentType, found, err := s3.GetFileSniffed("x43563", "dir", "file.png")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			So(contentType, ShouldEqual, "image/png")
+			defer reader.Close()
+
+			data, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(data, ShouldResemble, content)
+		})
+
+		Convey("Short file", func() {
+			server.put("x43563", "dir/file.html", []byte("<html><body>hi</body></html>"), nil)
+
+			reader, contentType, found, err := s3.GetFileSniffed("x43563", "dir", "file.html")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			So(contentType, ShouldEqual, "text/html; charset=utf-8")
+			defer reader.Close()
+
+			data, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "<html><body>hi</body></html>")
+		})
+
+		Convey("Missing", func() {
+			reader, _, found, err := s3.GetFileSniffed("x43563", "dir", "file.png")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+			So(reader, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package s3
+
+import (
+	"io"
+	"path/filepath"
+
+	minio "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/encrypt"
+	"github.com/pkg/errors"
+)
+
+// CreateFileSSEC make new file encrypted by the server with the given 32 byte
+// customer provided key. The server does not store the key, the same key must
+// be given to read the file.
+func (s helper) CreateFileSSEC(bucket, directory, fileName string, content io.Reader, length int64, mime string, key []byte) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	sse, err := encrypt.NewSSEC(key)
+	if err != nil {
+		return errors.Wrap(err, "invalid key")
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType:          mime,
+		ServerSideEncryption: sse,
+	}
+
+	return s.putObject(bucket, s.uploadKey(directory, fileName), content, length, opts)
+}
+
+// GetFileSSEC returns the file encrypted with the given customer provided
+// key. The found flag is false if the file does not exist. Reading the file
+// with another key fails. The caller must close the object.
+func (s helper) GetFileSSEC(bucket, directory, filename string, key []byte) (*minio.Object, bool, error) {
+	if !s.Enabled {
+		return nil, false, ErrServerDisabled
+	}
+
+	sse, err := encrypt.NewSSEC(key)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "invalid key")
+	}
+
+	obj, _, found, err := s.openObject(bucket, filepath.Join(directory, filename), minio.GetObjectOptions{ServerSideEncryption: sse})
+	return obj, found, err
+}
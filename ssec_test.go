@@ -0,0 +1,81 @@
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSSEC(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+
+	Convey("CreateFileSSEC", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CreateFileSSEC("x43563", "dir", "file.txt", strings.NewReader("secret"), 6, "text/plain", key)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid key", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			err := s3.CreateFileSSEC("x43563", "dir", "file.txt", strings.NewReader("secret"), 6, "text/plain", []byte("short"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("GetFileSSEC", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+
+		s3 := newTestHelper(server.Server)
+		err := s3.CreateFileSSEC("x43563", "dir", "file.txt", strings.NewReader("secret"), 6, "text/plain", key)
+		So(err, ShouldBeNil)
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetFileSSEC("x43563", "dir", "file.txt", key)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Matching key", func() {
+			obj, found, err := s3.GetFileSSEC("x43563", "dir", "file.txt", key)
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			defer obj.Close()
+
+			data, err := ioutil.ReadAll(obj)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "secret")
+		})
+
+		Convey("Wrong key", func() {
+			_, found, err := s3.GetFileSSEC("x43563", "dir", "file.txt", bytes.Repeat([]byte("x"), 32))
+			So(err, ShouldNotBeNil)
+			So(found, ShouldBeFalse)
+		})
+
+		Convey("Invalid key", func() {
+			_, _, err := s3.GetFileSSEC("x43563", "dir", "file.txt", nil)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Not found", func() {
+			obj, found, err := s3.GetFileSSEC("x43563", "dir", "missing.txt", key)
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+			So(obj, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,169 @@
+package s3
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// statConcurrency is the number of the files statted at the same time.
+const statConcurrency = 8
+
+// statFile returns the object info of the file. The found flag is false if the
+// file does not exist.
+func (s helper) statFile(bucket, directory, filename string) (minio.ObjectInfo, bool, error) {
+	return s.statObject(bucket, filepath.Join(directory, filename))
+}
+
+// objectExists returns whether the object exists, like FileExists does.
+func (s helper) objectExists(bucket, key string) (bool, error) {
+	cacheKey := fileCacheKey(bucket, key)
+	if exists, ok := s.cache.get(cacheKey); ok {
+		return exists, nil
+	}
+
+	_, found, err := s.statObject(bucket, key)
+	if err != nil {
+		return false, err
+	}
+
+	s.cache.set(cacheKey, found)
+	return found, nil
+}
+
+// statObject returns the info of the object. The found flag is false if the
+// object does not exist.
+func (s helper) statObject(bucket, key string) (minio.ObjectInfo, bool, error) {
+	info, err := s.Client.StatObject(bucket, key, minio.StatObjectOptions{})
+	if err, ok := err.(minio.ErrorResponse); ok && (err.Code == "NoSuchKey") {
+		return minio.ObjectInfo{}, false, nil
+	}
+	if err != nil {
+		return minio.ObjectInfo{}, false, errors.Wrap(err, "StatObject failed")
+	}
+
+	return info, true, nil
+}
+
+// GetETag returns the ETag of the file.
+func (s helper) GetETag(bucket, directory, filename string) (string, bool, error) {
+	if !s.Enabled {
+		return "", false, ErrServerDisabled
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	return info.ETag, true, nil
+}
+
+// GetUserMetadata returns the user metadata of the file, the x-amz-meta-*
+// headers without the prefix. The keys are lower case, as S3 stores them.
+func (s helper) GetUserMetadata(bucket, directory, filename string) (map[string]string, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("file not found")
+	}
+
+	metadata := map[string]string{}
+	for k, v := range info.Metadata {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-meta-") && len(v) > 0 {
+			metadata[strings.TrimPrefix(k, "x-amz-meta-")] = v[0]
+		}
+	}
+
+	return metadata, nil
+}
+
+// GetLastModified returns the last modification time of the file.
+func (s helper) GetLastModified(bucket, directory, filename string) (time.Time, bool, error) {
+	if !s.Enabled {
+		return time.Time{}, false, ErrServerDisabled
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil || !found {
+		return time.Time{}, false, err
+	}
+
+	return info.LastModified, true, nil
+}
+
+// FileExistsConsistent returns the file exists or not. A missing file is
+// checked again up to retries times, waiting delay between the checks, as a
+// just uploaded file may not be visible yet on eventually consistent servers.
+func (s helper) FileExistsConsistent(bucket, directory, filename string, retries int, delay time.Duration) (bool, error) {
+	if !s.Enabled {
+		return false, ErrServerDisabled
+	}
+
+	for i := 0; ; i++ {
+		_, found, err := s.statFile(bucket, directory, filename)
+		if err != nil {
+			return false, err
+		}
+		if found || i >= retries {
+			s.cache.set(fileCacheKey(bucket, filepath.Join(directory, filename)), found)
+			return found, nil
+		}
+
+		time.Sleep(delay)
+	}
+}
+
+// StatFiles returns the object info of the files, keyed by the object keys of
+// the references. The missing files are left out of the map. The files which
+// could be statted are returned even if others failed, the failures are
+// returned as BatchError.
+func (s helper) StatFiles(bucket string, keys []KeyRef) (map[string]minio.ObjectInfo, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	var mu sync.Mutex
+	infos := map[string]minio.ObjectInfo{}
+	failed := BatchError{}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, statConcurrency)
+	for _, ref := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ref KeyRef) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			info, found, err := s.statFile(bucket, ref.Directory, ref.FileName)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[ref.Key()] = err
+				return
+			}
+			if found {
+				infos[ref.Key()] = info
+			}
+		}(ref)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return infos, failed
+	}
+	return infos, nil
+}
@@ -0,0 +1,227 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStat(t *testing.T) {
+	Convey("GetUserMetadata", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.GetUserMetadata("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Round trip", func() {
+			err := s3.CreateFile("x43563", "dir", "file.txt", strings.NewReader("asdf"), 4, "text/plain",
+				WithMetadata("Owner", "alice"),
+				WithHeader("X-Amz-Meta-Project", "apollo"),
+				WithMetadata("source-system", "crm"),
+				WithHeader("Cache-Control", "no-cache"),
+			)
+			So(err, ShouldBeNil)
+
+			metadata, err := s3.GetUserMetadata("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(metadata, ShouldResemble, map[string]string{
+				"owner":         "alice",
+				"project":       "apollo",
+				"source-system": "crm",
+			})
+		})
+
+		Convey("No metadata", func() {
+			server.put("x43563", "dir/plain.txt", []byte("asdf"), nil)
+
+			metadata, err := s3.GetUserMetadata("x43563", "dir", "plain.txt")
+			So(err, ShouldBeNil)
+			So(metadata, ShouldBeEmpty)
+		})
+
+		Convey("Missing file", func() {
+			_, err := s3.GetUserMetadata("x43563", "dir", "missing.txt")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("GetETag", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Found", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+				w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			etag, found, err := s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			So(etag, ShouldEqual, "d41d8cd98f00b204e9800998ecf8427e")
+		})
+
+		Convey("Not found", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			etag, found, err := s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+			So(etag, ShouldBeEmpty)
+		})
+
+		Convey("Error", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			_, found, err := s3.GetETag("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+			So(found, ShouldBeFalse)
+		})
+	})
+
+	Convey("GetLastModified", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetLastModified("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Found", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			modified, found, err := s3.GetLastModified("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			So(modified.Equal(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)), ShouldBeTrue)
+		})
+
+		Convey("Not found", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			modified, found, err := s3.GetLastModified("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+			So(modified.IsZero(), ShouldBeTrue)
+		})
+	})
+
+	Convey("FileExistsConsistent", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.FileExistsConsistent("x43563", "dir", "file.txt", 3, time.Millisecond)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Found after a miss", func() {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			exists, err := s3.FileExistsConsistent("x43563", "dir", "file.txt", 3, time.Millisecond)
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+			So(requests, ShouldEqual, 2)
+		})
+
+		Convey("Missing", func() {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			exists, err := s3.FileExistsConsistent("x43563", "dir", "file.txt", 2, time.Millisecond)
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+			So(requests, ShouldEqual, 3)
+		})
+	})
+
+	Convey("StatFiles", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.StatFiles("x43563", []KeyRef{{Directory: "dir", FileName: "a.txt"}})
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/a.txt", []byte("asdf"), nil)
+		server.put("x43563", "dir/b.txt", []byte("qwerty"), nil)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Success", func() {
+			infos, err := s3.StatFiles("x43563", []KeyRef{
+				{Directory: "dir", FileName: "a.txt"},
+				{Directory: "dir", FileName: "missing.txt"},
+				{Directory: "dir", FileName: "b.txt"},
+			})
+			So(err, ShouldBeNil)
+			So(infos, ShouldHaveLength, 2)
+			So(infos["dir/a.txt"].Size, ShouldEqual, 4)
+			So(infos["dir/b.txt"].Size, ShouldEqual, 6)
+			So(infos, ShouldNotContainKey, "dir/missing.txt")
+		})
+
+		Convey("Empty", func() {
+			infos, err := s3.StatFiles("x43563", nil)
+			So(err, ShouldBeNil)
+			So(infos, ShouldBeEmpty)
+		})
+	})
+}
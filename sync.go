@@ -0,0 +1,144 @@
+package s3
+
+import (
+	"sort"
+	"strings"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// SyncResult represents the outcome of a prefix synchronization.
+type SyncResult struct {
+	Copied  int
+	Deleted int
+	Skipped int
+}
+
+// SyncPrefix copies the new and changed objects from the source prefix to the
+// destination prefix. Objects are compared by their ETag and size, see
+// syncedCopy for the objects larger than 5GiB. If deleteExtra is set, the
+// destination objects which are missing from the source are removed.
+func (s helper) SyncPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string, deleteExtra bool) (SyncResult, error) {
+	result := SyncResult{}
+	if !s.Enabled {
+		return result, ErrServerDisabled
+	}
+
+	src, err := s.listPrefix(srcBucket, srcPrefix)
+	if err != nil {
+		return result, err
+	}
+
+	dst, err := s.listPrefix(dstBucket, dstPrefix)
+	if err != nil {
+		return result, err
+	}
+
+	for name, obj := range src {
+		existing, ok := dst[name]
+		if ok && syncedCopy(obj, existing) {
+			result.Skipped++
+			continue
+		}
+
+		err = s.copyObject(srcBucket, obj.Key, dstBucket, dstPrefix+name, obj.Size, nil)
+		if err != nil {
+			return result, err
+		}
+		s.cache.set(fileCacheKey(dstBucket, dstPrefix+name), true)
+		result.Copied++
+	}
+
+	if !deleteExtra {
+		return result, nil
+	}
+
+	for name, obj := range dst {
+		if _, ok := src[name]; ok {
+			continue
+		}
+
+		err = s.removeObject(dstBucket, obj.Key)
+		if err != nil {
+			return result, errors.Wrap(err, "RemoveObject failed")
+		}
+		result.Deleted++
+	}
+
+	return result, nil
+}
+
+// DiffPrefix compares the objects under the source prefix with the ones under
+// the destination prefix by their ETag and size, like SyncPrefix does. It
+// returns the sorted keys, relative to the prefixes, of the objects which are
+// only in the source, only in the destination, and in both but differing, so
+// a sync can be planned before it is applied.
+func (s helper) DiffPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string) ([]string, []string, []string, error) {
+	if !s.Enabled {
+		return nil, nil, nil, ErrServerDisabled
+	}
+
+	src, err := s.listPrefix(srcBucket, srcPrefix)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	dst, err := s.listPrefix(dstBucket, dstPrefix)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	onlyInSrc, onlyInDst, differing := []string{}, []string{}, []string{}
+	for name, obj := range src {
+		existing, ok := dst[name]
+		if !ok {
+			onlyInSrc = append(onlyInSrc, name)
+		} else if !syncedCopy(obj, existing) {
+			differing = append(differing, name)
+		}
+	}
+	for name := range dst {
+		if _, ok := src[name]; !ok {
+			onlyInDst = append(onlyInDst, name)
+		}
+	}
+
+	sort.Strings(onlyInSrc)
+	sort.Strings(onlyInDst)
+	sort.Strings(differing)
+	return onlyInSrc, onlyInDst, differing, nil
+}
+
+// syncedCopy checks whether the destination object is a copy of the source
+// object by their ETag and size. The objects larger than 5GiB are copied in
+// parts, so their copies get another ETag, those match by size if the copy is
+// not older than the source. A changed source is newer than its copy, but a
+// destination object of the same size written after the source is taken for a
+// copy too.
+func syncedCopy(src, dst minio.ObjectInfo) bool {
+	if src.Size != dst.Size {
+		return false
+	}
+	if src.ETag == dst.ETag {
+		return true
+	}
+	return src.Size > maxCopyObjectSize && !dst.LastModified.Before(src.LastModified)
+}
+
+// listPrefix lists the objects under the prefix recursively. The returned map
+// is keyed by the object keys relative to the prefix.
+func (s helper) listPrefix(bucket, prefix string) (map[string]minio.ObjectInfo, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	objects := map[string]minio.ObjectInfo{}
+	for obj := range s.Client.ListObjectsV2(bucket, prefix, true, doneCh) {
+		if obj.Err != nil {
+			return nil, errors.Wrap(obj.Err, "list object error")
+		}
+		objects[strings.TrimPrefix(obj.Key, prefix)] = obj
+	}
+
+	return objects, nil
+}
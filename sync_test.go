@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSync(t *testing.T) {
+	Convey("SyncPrefix", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.SyncPrefix("src-bucket", "a/", "dst-bucket", "b/", true)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Sync", func() {
+			server := newFakeS3()
+			defer server.Close()
+
+			server.put("src-bucket", "a/same.txt", []byte("same"), nil)
+			server.put("src-bucket", "a/changed.txt", []byte("new content"), nil)
+			server.put("src-bucket", "a/new.txt", []byte("new"), nil)
+			server.put("dst-bucket", "b/same.txt", []byte("same"), nil)
+			server.put("dst-bucket", "b/changed.txt", []byte("old content"), nil)
+			server.put("dst-bucket", "b/extra.txt", []byte("extra"), nil)
+
+			s3 := newTestHelper(server.Server)
+
+			Convey("Without delete", func() {
+				result, err := s3.SyncPrefix("src-bucket", "a/", "dst-bucket", "b/", false)
+				So(err, ShouldBeNil)
+				So(result, ShouldResemble, SyncResult{Copied: 2, Skipped: 1})
+				So(server.keys("dst-bucket"), ShouldResemble, []string{"b/changed.txt", "b/extra.txt", "b/new.txt", "b/same.txt"})
+
+				obj, ok := server.get("dst-bucket", "b/changed.txt")
+				So(ok, ShouldBeTrue)
+				So(string(obj.data), ShouldEqual, "new content")
+			})
+
+			Convey("With delete", func() {
+				result, err := s3.SyncPrefix("src-bucket", "a/", "dst-bucket", "b/", true)
+				So(err, ShouldBeNil)
+				So(result, ShouldResemble, SyncResult{Copied: 2, Deleted: 1, Skipped: 1})
+				So(server.keys("dst-bucket"), ShouldResemble, []string{"b/changed.txt", "b/new.txt", "b/same.txt"})
+			})
+		})
+	})
+
+	Convey("DiffPrefix", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, _, err := s3.DiffPrefix("src-bucket", "a/", "dst-bucket", "b/")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Differences", func() {
+			server := newFakeS3()
+			defer server.Close()
+
+			server.put("src-bucket", "a/same.txt", []byte("same"), nil)
+			server.put("src-bucket", "a/changed.txt", []byte("new content"), nil)
+			server.put("src-bucket", "a/sub/new.txt", []byte("new"), nil)
+			server.put("src-bucket", "a/new.txt", []byte("new"), nil)
+			server.put("dst-bucket", "b/same.txt", []byte("same"), nil)
+			server.put("dst-bucket", "b/changed.txt", []byte("old content"), nil)
+			server.put("dst-bucket", "b/extra.txt", []byte("extra"), nil)
+
+			s3 := newTestHelper(server.Server)
+			onlyInSrc, onlyInDst, differing, err := s3.DiffPrefix("src-bucket", "a/", "dst-bucket", "b/")
+			So(err, ShouldBeNil)
+			So(onlyInSrc, ShouldResemble, []string{"new.txt", "sub/new.txt"})
+			So(onlyInDst, ShouldResemble, []string{"extra.txt"})
+			So(differing, ShouldResemble, []string{"changed.txt"})
+
+			// Nothing is changed.
+			So(server.keys("dst-bucket"), ShouldResemble, []string{"b/changed.txt", "b/extra.txt", "b/same.txt"})
+		})
+	})
+
+	Convey("syncedCopy", t, func() {
+		modified := time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC)
+		small := minio.ObjectInfo{ETag: "a", Size: 4, LastModified: modified}
+		large := minio.ObjectInfo{ETag: "a", Size: maxCopyObjectSize + 1, LastModified: modified}
+
+		copied := func(src minio.ObjectInfo, etag string, size int64, lastModified time.Time) bool {
+			return syncedCopy(src, minio.ObjectInfo{ETag: etag, Size: size, LastModified: lastModified})
+		}
+
+		So(copied(small, "a", 4, modified), ShouldBeTrue)
+		So(copied(small, "b", 4, modified.Add(time.Hour)), ShouldBeFalse)
+		So(copied(small, "a", 5, modified), ShouldBeFalse)
+
+		// The copies made in parts have another ETag.
+		So(copied(large, "b-2", large.Size, modified.Add(time.Hour)), ShouldBeTrue)
+		So(copied(large, "b-2", large.Size, modified), ShouldBeTrue)
+		So(copied(large, "b-2", large.Size, modified.Add(-time.Hour)), ShouldBeFalse)
+		So(copied(large, "b-2", large.Size+1, modified.Add(time.Hour)), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,98 @@
+package s3
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket which limits the transferred bytes per second.
+// The bucket holds at most one second worth of tokens.
+type rateLimiter struct {
+	rate int64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a new rate limiter with a full bucket.
+func newRateLimiter(rate int64) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// wait takes n tokens from the bucket and blocks until the bucket is no
+// longer in debt.
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	tokens := l.tokens
+	l.mu.Unlock()
+
+	if tokens < 0 {
+		time.Sleep(time.Duration(-tokens / float64(l.rate) * float64(time.Second)))
+	}
+}
+
+// throttledReader limits the read speed of the underlying reader.
+type throttledReader struct {
+	io.ReadCloser
+	limiter *rateLimiter
+}
+
+// Read reads at most one second worth of bytes and waits for the tokens.
+func (r throttledReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > r.limiter.rate {
+		p = p[:r.limiter.rate]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
+
+// throttledTransport throttles the request and response bodies. The limit is
+// shared between all the transfers.
+type throttledTransport struct {
+	transport http.RoundTripper
+	limiter   *rateLimiter
+}
+
+// newThrottledTransport wraps the transport to transfer at most the given
+// bytes per second.
+func newThrottledTransport(transport http.RoundTripper, bytesPerSecond int64) *throttledTransport {
+	return &throttledTransport{
+		transport: transport,
+		limiter:   newRateLimiter(bytesPerSecond),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		throttled := *req
+		throttled.Body = throttledReader{ReadCloser: req.Body, limiter: t.limiter}
+		req = &throttled
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = throttledReader{ReadCloser: resp.Body, limiter: t.limiter}
+	return resp, nil
+}
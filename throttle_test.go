@@ -0,0 +1,79 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestThrottle(t *testing.T) {
+	Convey("rateLimiter", t, func() {
+		limiter := newRateLimiter(1000)
+
+		start := time.Now()
+		limiter.wait(1000)
+		So(time.Since(start), ShouldBeLessThan, 100*time.Millisecond)
+
+		limiter.wait(500)
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 500*time.Millisecond)
+	})
+
+	Convey("MaxBytesPerSecond", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+
+		config := testConfig(server.Server)
+		config.MaxBytesPerSecond = 256 * 1024
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		content := make([]byte, 384*1024)
+
+		Convey("Upload", func() {
+			start := time.Now()
+			err := s3.CreateFile("x43563", "dir", "file.bin", bytes.NewReader(content), int64(len(content)), "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 500*time.Millisecond)
+		})
+
+		Convey("Download", func() {
+			server.put("x43563", "dir/file.bin", content, nil)
+
+			start := time.Now()
+			obj, err := s3.GetFile("x43563", "dir", "file.bin")
+			So(err, ShouldBeNil)
+			data, err := ioutil.ReadAll(obj)
+			So(err, ShouldBeNil)
+			So(data, ShouldHaveLength, len(content))
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 500*time.Millisecond)
+		})
+
+		Convey("Retried upload", func() {
+			config.RetryDelay = time.Millisecond
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			h := s3.(*helper)
+
+			uploadID, err := h.newMultipartUpload(context.Background(), "x43563", "dir/file.bin", nil)
+			So(err, ShouldBeNil)
+
+			// Every attempt is throttled, 576KiB in all.
+			server.fail("PUT", "SlowDown", "SlowDown")
+			start := time.Now()
+			_, err = h.putObjectPart(context.Background(), "x43563", "dir/file.bin", uploadID, 1, content[:192*1024], nil)
+			So(err, ShouldBeNil)
+			So(server.count("PUT"), ShouldEqual, 3)
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, time.Second)
+		})
+
+		Convey("Invalid", func() {
+			config.MaxBytesPerSecond = -1
+			_, err := New(config)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
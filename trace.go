@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"context"
+
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Tracer creates the spans of the operations of the helper, for example with
+// an OpenTelemetry tracer. It is set by Config.Tracer.
+type Tracer interface {
+	// Start starts the span of an operation, like CreateFile, as a child of
+	// the span of ctx, if any. The returned context carries the new span.
+	Start(ctx context.Context, operation string) (context.Context, Span)
+}
+
+// Span is a span started by Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span.
+	SetAttribute(key string, value interface{})
+	// SetError marks the span as failed.
+	SetError(err error)
+	// End ends the span.
+	End()
+}
+
+// Attributes of the spans.
+const (
+	AttributeBucket     = "s3.bucket"
+	AttributeKey        = "s3.key"
+	AttributeSize       = "s3.size"
+	AttributeStatusCode = "http.status_code"
+)
+
+// tracedHelper creates a span for every operation of the helper which sends
+// requests to the server. The attributes are the bucket, the key and the size
+// of the uploaded content if they are known, the failed operations set the
+// error and the status code of the error response. The methods called by
+// an operation do not create spans of their own. The helper is not embedded,
+// so a method missing from traced.go is a compile error instead of an
+// untraced operation.
+type tracedHelper struct {
+	helper *helper
+	tracer Tracer
+}
+
+var _ Helper = tracedHelper{}
+
+// start starts the span of the operation. The key is empty and the size is -1
+// if they are not known.
+func (t tracedHelper) start(ctx context.Context, operation, bucket, key string, size int64) (context.Context, Span) {
+	ctx, span := t.tracer.Start(ctx, operation)
+	if bucket != "" {
+		span.SetAttribute(AttributeBucket, bucket)
+	}
+	if key != "" {
+		span.SetAttribute(AttributeKey, key)
+	}
+	if size >= 0 {
+		span.SetAttribute(AttributeSize, size)
+	}
+	return ctx, span
+}
+
+// endSpan sets the error of the failed operation and ends the span.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.SetError(err)
+		if resp, ok := errors.Cause(err).(minio.ErrorResponse); ok && resp.StatusCode != 0 {
+			span.SetAttribute(AttributeStatusCode, resp.StatusCode)
+		}
+	}
+	span.End()
+}
+
+// WithRegion returns the helper of the region, tracing its operations too.
+func (t tracedHelper) WithRegion(region string) (Helper, error) {
+	helper, err := t.helper.withRegion(region)
+	if err != nil {
+		return nil, err
+	}
+	return tracedHelper{helper: helper, tracer: t.tracer}, nil
+}
@@ -0,0 +1,167 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordedSpan is a span recorded by recordingTracer.
+type recordedSpan struct {
+	operation  string
+	parent     *recordedSpan
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+// spanKey is the context key of the recorded spans.
+type spanKey struct{}
+
+// recordingTracer records the spans in memory.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, operation string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{operation: operation, parent: parent, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordedSpan) SetAttribute(key string, value interface{}) {
+	s.attributes[key] = value
+}
+
+func (s *recordedSpan) SetError(err error) {
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+func TestTrace(t *testing.T) {
+	Convey("Tracer", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+
+		tracer := &recordingTracer{}
+		config := testConfig(server.Server)
+		config.Tracer = tracer
+		config.RetryDelay = time.Millisecond
+		config.UploadPartSize = minUploadPartSize
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Operation", func() {
+			err := s3.CreateFile("x43563", "dir", "file.txt", strings.NewReader("asdf"), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(tracer.spans, ShouldHaveLength, 1)
+
+			span := tracer.spans[0]
+			So(span.operation, ShouldEqual, "CreateFile")
+			So(span.attributes[AttributeBucket], ShouldEqual, "x43563")
+			So(span.attributes[AttributeKey], ShouldEqual, "dir/file.txt")
+			So(span.attributes[AttributeSize], ShouldEqual, 4)
+			So(span.err, ShouldBeNil)
+			So(span.ended, ShouldBeTrue)
+		})
+
+		Convey("Multipart upload", func() {
+			content := bytes.Repeat([]byte("x"), minUploadPartSize+1)
+			err := s3.CreateFileStream("x43563", "dir", "file.bin", bytes.NewReader(content), "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(server.count("PUT"), ShouldEqual, 2)
+
+			So(tracer.spans, ShouldHaveLength, 1)
+			So(tracer.spans[0].operation, ShouldEqual, "CreateFileStream")
+			So(tracer.spans[0].attributes, ShouldNotContainKey, AttributeSize)
+		})
+
+		Convey("Retried", func() {
+			server.fail("PUT", "SlowDown")
+			err := s3.CreateFile("x43563", "dir", "file.txt", strings.NewReader("asdf"), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(server.count("PUT"), ShouldEqual, 2)
+			So(tracer.spans, ShouldHaveLength, 1)
+			So(tracer.spans[0].err, ShouldBeNil)
+		})
+
+		Convey("Error", func() {
+			server.fail("GET", "SlowDown", "SlowDown", "SlowDown", "SlowDown")
+			_, _, _, _, err := s3.GetObjectLockConfig("x43563")
+			So(err, ShouldNotBeNil)
+
+			So(tracer.spans, ShouldHaveLength, 1)
+			span := tracer.spans[0]
+			So(span.err, ShouldNotBeNil)
+			So(span.attributes[AttributeStatusCode], ShouldEqual, 503)
+			So(span.ended, ShouldBeTrue)
+		})
+
+		Convey("Parent", func() {
+			ctx, parent := tracer.Start(context.Background(), "request")
+			err := s3.CreateFileWithContext(ctx, "x43563", "dir", "file.txt", strings.NewReader("asdf"), 4, "text/plain")
+			So(err, ShouldBeNil)
+
+			So(tracer.spans, ShouldHaveLength, 2)
+			So(tracer.spans[1].operation, ShouldEqual, "CreateFileWithContext")
+			So(tracer.spans[1].parent, ShouldEqual, parent)
+		})
+
+		Convey("Bucket", func() {
+			_, err := s3.BucketExists("x43563")
+			So(err, ShouldBeNil)
+
+			span := tracer.spans[0]
+			So(span.operation, ShouldEqual, "BucketExists")
+			So(span.attributes[AttributeBucket], ShouldEqual, "x43563")
+			So(span.attributes, ShouldNotContainKey, AttributeKey)
+		})
+
+		Convey("Region", func() {
+			regional, err := s3.WithRegion("eu-west-1")
+			So(err, ShouldBeNil)
+
+			_, err = regional.BucketExists("x43563")
+			So(err, ShouldBeNil)
+			So(tracer.spans, ShouldHaveLength, 1)
+			So(tracer.spans[0].operation, ShouldEqual, "BucketExists")
+		})
+
+		Convey("Key template", func() {
+			config.KeyTemplate = "{year}/{dir}/{file}"
+			config.Clock = func() time.Time {
+				return time.Date(2018, 12, 31, 23, 30, 0, 0, time.UTC)
+			}
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.CreateFile("x43563", "dir", "file.txt", strings.NewReader("asdf"), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(tracer.spans[0].attributes[AttributeKey], ShouldEqual, "2018/dir/file.txt")
+			So(server.keys("x43563"), ShouldResemble, []string{"2018/dir/file.txt"})
+
+			key, _, err := s3.CreateFileDedup("x43563", "dir", strings.NewReader("asdf"), "text/plain")
+			So(err, ShouldBeNil)
+			So(tracer.spans[1].attributes[AttributeKey], ShouldEqual, key)
+		})
+
+		Convey("Local", func() {
+			_, err := s3.PresignedGetURLString("x43563", "dir", "file.txt", time.Hour)
+			So(err, ShouldBeNil)
+			So(s3.GetS3Host(), ShouldNotBeEmpty)
+			So(tracer.spans, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,768 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	minio "github.com/minio/minio-go"
+)
+
+// The operations of tracedHelper. Every method which sends requests to the
+// server starts its span and calls the wrapped helper, the local ones, like
+// the presigned URLs, are not traced. The uploads record the key resolved
+// with the key template.
+
+func (t tracedHelper) CreateBucket(name string) error {
+	_, span := t.start(context.Background(), "CreateBucket", name, "", -1)
+	err := t.helper.CreateBucket(name)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateDirectory(bucket string, name string) error {
+	_, span := t.start(context.Background(), "CreateDirectory", bucket, "", -1)
+	err := t.helper.CreateDirectory(bucket, name)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFile(bucket, directory, file string, content io.Reader, length int64, mime string, opts ...UploadOption) error {
+	_, span := t.start(context.Background(), "CreateFile", bucket, t.helper.uploadKey(directory, file), length)
+	err := t.helper.CreateFile(bucket, directory, file, content, length, mime, opts...)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) PutObject(bucket, key string, content io.Reader, length int64, opts minio.PutObjectOptions) error {
+	_, span := t.start(context.Background(), "PutObject", bucket, key, length)
+	err := t.helper.PutObject(bucket, key, content, length, opts)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileWithContext(ctx context.Context, bucket, directory, fileName string, content io.Reader, length int64, mime string, opts ...UploadOption) error {
+	ctx, span := t.start(ctx, "CreateFileWithContext", bucket, t.helper.uploadKey(directory, fileName), length)
+	err := t.helper.CreateFileWithContext(ctx, bucket, directory, fileName, content, length, mime, opts...)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileWithStorageClass(bucket, directory, fileName string, content io.Reader, length int64, mime, storageClass string) error {
+	_, span := t.start(context.Background(), "CreateFileWithStorageClass", bucket, t.helper.uploadKey(directory, fileName), length)
+	err := t.helper.CreateFileWithStorageClass(bucket, directory, fileName, content, length, mime, storageClass)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileWithExpires(bucket, directory, fileName string, content io.Reader, length int64, mime string, expires time.Time) error {
+	_, span := t.start(context.Background(), "CreateFileWithExpires", bucket, t.helper.uploadKey(directory, fileName), length)
+	err := t.helper.CreateFileWithExpires(bucket, directory, fileName, content, length, mime, expires)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileWithCacheControl(bucket, directory, fileName string, content io.Reader, length int64, mime, cacheControl string) error {
+	_, span := t.start(context.Background(), "CreateFileWithCacheControl", bucket, t.helper.uploadKey(directory, fileName), length)
+	err := t.helper.CreateFileWithCacheControl(bucket, directory, fileName, content, length, mime, cacheControl)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileAtomic(bucket, directory, fileName string, content io.Reader, length int64, mime string) error {
+	_, span := t.start(context.Background(), "CreateFileAtomic", bucket, t.helper.uploadKey(directory, fileName), length)
+	err := t.helper.CreateFileAtomic(bucket, directory, fileName, content, length, mime)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) GetOrCreateFile(bucket, directory, fileName string, content io.Reader, length int64, mime string) (minio.ObjectInfo, bool, error) {
+	_, span := t.start(context.Background(), "GetOrCreateFile", bucket, filepath.Join(directory, fileName), length)
+	info, ok, err := t.helper.GetOrCreateFile(bucket, directory, fileName, content, length, mime)
+	endSpan(span, err)
+	return info, ok, err
+}
+
+func (t tracedHelper) CreateFileIfNotExists(bucket, directory, fileName string, content io.Reader, length int64, mime string) (bool, error) {
+	_, span := t.start(context.Background(), "CreateFileIfNotExists", bucket, filepath.Join(directory, fileName), length)
+	ok, err := t.helper.CreateFileIfNotExists(bucket, directory, fileName, content, length, mime)
+	endSpan(span, err)
+	return ok, err
+}
+
+func (t tracedHelper) CreateFileFromRequest(bucket, directory, fileName string, r *http.Request) error {
+	_, span := t.start(context.Background(), "CreateFileFromRequest", bucket, t.helper.uploadKey(directory, fileName), -1)
+	err := t.helper.CreateFileFromRequest(bucket, directory, fileName, r)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileSeekable(bucket, directory, fileName string, ra io.ReaderAt, size int64, mime string) error {
+	_, span := t.start(context.Background(), "CreateFileSeekable", bucket, t.helper.uploadKey(directory, fileName), size)
+	err := t.helper.CreateFileSeekable(bucket, directory, fileName, ra, size, mime)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileStream(bucket, directory, fileName string, content io.Reader, mime string) error {
+	_, span := t.start(context.Background(), "CreateFileStream", bucket, t.helper.uploadKey(directory, fileName), -1)
+	err := t.helper.CreateFileStream(bucket, directory, fileName, content, mime)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) StartResumableUpload(bucket, directory, filename string, size int64, mime string, store UploadStore) (string, error) {
+	_, span := t.start(context.Background(), "StartResumableUpload", bucket, t.helper.uploadKey(directory, filename), size)
+	id, err := t.helper.StartResumableUpload(bucket, directory, filename, size, mime, store)
+	endSpan(span, err)
+	return id, err
+}
+
+func (t tracedHelper) ResumeUpload(id string, content io.ReaderAt, store UploadStore) error {
+	_, span := t.start(context.Background(), "ResumeUpload", "", "", -1)
+	err := t.helper.ResumeUpload(id, content, store)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileSSEC(bucket, directory, fileName string, content io.Reader, length int64, mime string, key []byte) error {
+	_, span := t.start(context.Background(), "CreateFileSSEC", bucket, t.helper.uploadKey(directory, fileName), length)
+	err := t.helper.CreateFileSSEC(bucket, directory, fileName, content, length, mime, key)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) GetFileSSEC(bucket, directory, filename string, key []byte) (*minio.Object, bool, error) {
+	_, span := t.start(context.Background(), "GetFileSSEC", bucket, filepath.Join(directory, filename), -1)
+	obj, ok, err := t.helper.GetFileSSEC(bucket, directory, filename, key)
+	endSpan(span, err)
+	return obj, ok, err
+}
+
+func (t tracedHelper) UploadDirectory(bucket, localDir, destPrefix string, concurrency int) (UploadResult, error) {
+	_, span := t.start(context.Background(), "UploadDirectory", bucket, "", -1)
+	result, err := t.helper.UploadDirectory(bucket, localDir, destPrefix, concurrency)
+	endSpan(span, err)
+	return result, err
+}
+
+func (t tracedHelper) DownloadDirectory(bucket, prefix, localDir string, concurrency int) (DownloadResult, error) {
+	_, span := t.start(context.Background(), "DownloadDirectory", bucket, "", -1)
+	result, err := t.helper.DownloadDirectory(bucket, prefix, localDir, concurrency)
+	endSpan(span, err)
+	return result, err
+}
+
+func (t tracedHelper) DownloadToFileParallel(bucket, directory, filename, localPath string, parts int) error {
+	_, span := t.start(context.Background(), "DownloadToFileParallel", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.DownloadToFileParallel(bucket, directory, filename, localPath, parts)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) EnsureDirectoryMarkers(bucket, prefix string) (int, error) {
+	_, span := t.start(context.Background(), "EnsureDirectoryMarkers", bucket, "", -1)
+	n, err := t.helper.EnsureDirectoryMarkers(bucket, prefix)
+	endSpan(span, err)
+	return n, err
+}
+
+func (t tracedHelper) GetObjectParts(bucket, directory, filename string) (int, error) {
+	_, span := t.start(context.Background(), "GetObjectParts", bucket, filepath.Join(directory, filename), -1)
+	parts, err := t.helper.GetObjectParts(bucket, directory, filename)
+	endSpan(span, err)
+	return parts, err
+}
+
+func (t tracedHelper) ListIncompleteUploads(bucket, prefix string) ([]minio.ObjectMultipartInfo, error) {
+	_, span := t.start(context.Background(), "ListIncompleteUploads", bucket, "", -1)
+	uploads, err := t.helper.ListIncompleteUploads(bucket, prefix)
+	endSpan(span, err)
+	return uploads, err
+}
+
+func (t tracedHelper) AbortIncompleteUploads(bucket, prefix string, olderThan time.Duration) (int, error) {
+	_, span := t.start(context.Background(), "AbortIncompleteUploads", bucket, "", -1)
+	n, err := t.helper.AbortIncompleteUploads(bucket, prefix, olderThan)
+	endSpan(span, err)
+	return n, err
+}
+
+func (t tracedHelper) GetObjectLockConfig(bucket string) (bool, string, int, string, error) {
+	_, span := t.start(context.Background(), "GetObjectLockConfig", bucket, "", -1)
+	enabled, mode, validity, unit, err := t.helper.GetObjectLockConfig(bucket)
+	endSpan(span, err)
+	return enabled, mode, validity, unit, err
+}
+
+func (t tracedHelper) SetObjectLockConfig(bucket, mode string, validity int, unit string) error {
+	_, span := t.start(context.Background(), "SetObjectLockConfig", bucket, "", -1)
+	err := t.helper.SetObjectLockConfig(bucket, mode, validity, unit)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) BucketExists(bucket string) (bool, error) {
+	_, span := t.start(context.Background(), "BucketExists", bucket, "", -1)
+	ok, err := t.helper.BucketExists(bucket)
+	endSpan(span, err)
+	return ok, err
+}
+
+func (t tracedHelper) MustBucketExist(bucket string) error {
+	_, span := t.start(context.Background(), "MustBucketExist", bucket, "", -1)
+	err := t.helper.MustBucketExist(bucket)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) ListOfBucket() ([]string, error) {
+	_, span := t.start(context.Background(), "ListOfBucket", "", "", -1)
+	buckets, err := t.helper.ListOfBucket()
+	endSpan(span, err)
+	return buckets, err
+}
+
+func (t tracedHelper) ListBucketsDetailed() ([]minio.BucketInfo, error) {
+	_, span := t.start(context.Background(), "ListBucketsDetailed", "", "", -1)
+	buckets, err := t.helper.ListBucketsDetailed()
+	endSpan(span, err)
+	return buckets, err
+}
+
+func (t tracedHelper) BucketStats(bucket string) (int64, int64, error) {
+	_, span := t.start(context.Background(), "BucketStats", bucket, "", -1)
+	count, size, err := t.helper.BucketStats(bucket)
+	endSpan(span, err)
+	return count, size, err
+}
+
+func (t tracedHelper) ListOfBucketFolder(bucketName string, isRecursive bool, opts ...FolderOption) (*Folder, error) {
+	_, span := t.start(context.Background(), "ListOfBucketFolder", bucketName, "", -1)
+	folder, err := t.helper.ListOfBucketFolder(bucketName, isRecursive, opts...)
+	endSpan(span, err)
+	return folder, err
+}
+
+func (t tracedHelper) ListOfBucketFolderWithContext(ctx context.Context, bucketName string, isRecursive bool, opts ...FolderOption) (*Folder, error) {
+	ctx, span := t.start(ctx, "ListOfBucketFolderWithContext", bucketName, "", -1)
+	folder, err := t.helper.ListOfBucketFolderWithContext(ctx, bucketName, isRecursive, opts...)
+	endSpan(span, err)
+	return folder, err
+}
+
+func (t tracedHelper) GetFile(bucket, directory, filename string) (*minio.Object, error) {
+	_, span := t.start(context.Background(), "GetFile", bucket, filepath.Join(directory, filename), -1)
+	obj, err := t.helper.GetFile(bucket, directory, filename)
+	endSpan(span, err)
+	return obj, err
+}
+
+func (t tracedHelper) GetObjectRaw(bucket, key string, opts minio.GetObjectOptions) (*minio.Object, bool, error) {
+	_, span := t.start(context.Background(), "GetObjectRaw", bucket, key, -1)
+	obj, ok, err := t.helper.GetObjectRaw(bucket, key, opts)
+	endSpan(span, err)
+	return obj, ok, err
+}
+
+func (t tracedHelper) Fetch(bucket, directory, filename string) (*FetchResult, error) {
+	_, span := t.start(context.Background(), "Fetch", bucket, filepath.Join(directory, filename), -1)
+	result, err := t.helper.Fetch(bucket, directory, filename)
+	endSpan(span, err)
+	return result, err
+}
+
+func (t tracedHelper) ServeFile(w http.ResponseWriter, r *http.Request, bucket, directory, filename string) error {
+	_, span := t.start(context.Background(), "ServeFile", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.ServeFile(w, r, bucket, directory, filename)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) FileExists(bucket, directory, filename string) (bool, error) {
+	_, span := t.start(context.Background(), "FileExists", bucket, filepath.Join(directory, filename), -1)
+	ok, err := t.helper.FileExists(bucket, directory, filename)
+	endSpan(span, err)
+	return ok, err
+}
+
+func (t tracedHelper) FileExistsConsistent(bucket, directory, filename string, retries int, delay time.Duration) (bool, error) {
+	_, span := t.start(context.Background(), "FileExistsConsistent", bucket, filepath.Join(directory, filename), -1)
+	ok, err := t.helper.FileExistsConsistent(bucket, directory, filename, retries, delay)
+	endSpan(span, err)
+	return ok, err
+}
+
+func (t tracedHelper) VerifyFile(bucket, directory, filename, expectedSHA256 string) (bool, error) {
+	_, span := t.start(context.Background(), "VerifyFile", bucket, filepath.Join(directory, filename), -1)
+	ok, err := t.helper.VerifyFile(bucket, directory, filename, expectedSHA256)
+	endSpan(span, err)
+	return ok, err
+}
+
+func (t tracedHelper) RemoveBucket(bucket string) error {
+	_, span := t.start(context.Background(), "RemoveBucket", bucket, "", -1)
+	err := t.helper.RemoveBucket(bucket)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) RemoveDirectory(bucket, directory string) error {
+	_, span := t.start(context.Background(), "RemoveDirectory", bucket, "", -1)
+	err := t.helper.RemoveDirectory(bucket, directory)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) RemoveFile(bucket, directory, fileName string) error {
+	_, span := t.start(context.Background(), "RemoveFile", bucket, filepath.Join(directory, fileName), -1)
+	err := t.helper.RemoveFile(bucket, directory, fileName)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) RestoreObject(bucket, directory, filename string, days int, tier string) error {
+	_, span := t.start(context.Background(), "RestoreObject", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.RestoreObject(bucket, directory, filename, days, tier)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) IsRestored(bucket, directory, filename string) (bool, error) {
+	_, span := t.start(context.Background(), "IsRestored", bucket, filepath.Join(directory, filename), -1)
+	ok, err := t.helper.IsRestored(bucket, directory, filename)
+	endSpan(span, err)
+	return ok, err
+}
+
+func (t tracedHelper) SyncPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string, deleteExtra bool) (SyncResult, error) {
+	_, span := t.start(context.Background(), "SyncPrefix", srcBucket, "", -1)
+	result, err := t.helper.SyncPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix, deleteExtra)
+	endSpan(span, err)
+	return result, err
+}
+
+func (t tracedHelper) DiffPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string) (onlyInSrc, onlyInDst, differing []string, err error) {
+	_, span := t.start(context.Background(), "DiffPrefix", srcBucket, "", -1)
+	onlyInSrc, onlyInDst, differing, err = t.helper.DiffPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix)
+	endSpan(span, err)
+	return onlyInSrc, onlyInDst, differing, err
+}
+
+func (t tracedHelper) GetETag(bucket, directory, filename string) (string, bool, error) {
+	_, span := t.start(context.Background(), "GetETag", bucket, filepath.Join(directory, filename), -1)
+	etag, ok, err := t.helper.GetETag(bucket, directory, filename)
+	endSpan(span, err)
+	return etag, ok, err
+}
+
+func (t tracedHelper) StatFiles(bucket string, keys []KeyRef) (map[string]minio.ObjectInfo, error) {
+	_, span := t.start(context.Background(), "StatFiles", bucket, "", -1)
+	infos, err := t.helper.StatFiles(bucket, keys)
+	endSpan(span, err)
+	return infos, err
+}
+
+func (t tracedHelper) GetUserMetadata(bucket, directory, filename string) (map[string]string, error) {
+	_, span := t.start(context.Background(), "GetUserMetadata", bucket, filepath.Join(directory, filename), -1)
+	metadata, err := t.helper.GetUserMetadata(bucket, directory, filename)
+	endSpan(span, err)
+	return metadata, err
+}
+
+func (t tracedHelper) GetLastModified(bucket, directory, filename string) (time.Time, bool, error) {
+	_, span := t.start(context.Background(), "GetLastModified", bucket, filepath.Join(directory, filename), -1)
+	modified, ok, err := t.helper.GetLastModified(bucket, directory, filename)
+	endSpan(span, err)
+	return modified, ok, err
+}
+
+func (t tracedHelper) SetBucketReplication(bucket string, config ReplicationConfig) error {
+	_, span := t.start(context.Background(), "SetBucketReplication", bucket, "", -1)
+	err := t.helper.SetBucketReplication(bucket, config)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) GetBucketReplication(bucket string) (ReplicationConfig, error) {
+	_, span := t.start(context.Background(), "GetBucketReplication", bucket, "", -1)
+	config, err := t.helper.GetBucketReplication(bucket)
+	endSpan(span, err)
+	return config, err
+}
+
+func (t tracedHelper) AddReplicationRule(bucket, id, prefix, destinationARN string) error {
+	_, span := t.start(context.Background(), "AddReplicationRule", bucket, "", -1)
+	err := t.helper.AddReplicationRule(bucket, id, prefix, destinationARN)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) SetBucketEncryption(bucket, sseAlgorithm, kmsKeyID string) error {
+	_, span := t.start(context.Background(), "SetBucketEncryption", bucket, "", -1)
+	err := t.helper.SetBucketEncryption(bucket, sseAlgorithm, kmsKeyID)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) GetBucketEncryption(bucket string) (string, string, error) {
+	_, span := t.start(context.Background(), "GetBucketEncryption", bucket, "", -1)
+	algorithm, kmsKeyID, err := t.helper.GetBucketEncryption(bucket)
+	endSpan(span, err)
+	return algorithm, kmsKeyID, err
+}
+
+func (t tracedHelper) SetBucketWebsite(bucket, indexDocument, errorDocument string) error {
+	_, span := t.start(context.Background(), "SetBucketWebsite", bucket, "", -1)
+	err := t.helper.SetBucketWebsite(bucket, indexDocument, errorDocument)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) GetBucketWebsite(bucket string) (WebsiteConfig, error) {
+	_, span := t.start(context.Background(), "GetBucketWebsite", bucket, "", -1)
+	config, err := t.helper.GetBucketWebsite(bucket)
+	endSpan(span, err)
+	return config, err
+}
+
+func (t tracedHelper) VerifyCredentials(ctx context.Context) error {
+	ctx, span := t.start(ctx, "VerifyCredentials", "", "", -1)
+	err := t.helper.VerifyCredentials(ctx)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) BackendType() (string, error) {
+	_, span := t.start(context.Background(), "BackendType", "", "", -1)
+	backend, err := t.helper.BackendType()
+	endSpan(span, err)
+	return backend, err
+}
+
+func (t tracedHelper) ListFileVersions(bucket, prefix string) ([]minio.ObjectInfo, error) {
+	_, span := t.start(context.Background(), "ListFileVersions", bucket, "", -1)
+	objects, err := t.helper.ListFileVersions(bucket, prefix)
+	endSpan(span, err)
+	return objects, err
+}
+
+func (t tracedHelper) DeleteObjectVersion(bucket, directory, filename, versionID string) error {
+	_, span := t.start(context.Background(), "DeleteObjectVersion", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.DeleteObjectVersion(bucket, directory, filename, versionID)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) PruneVersions(bucket, prefix string, keep int) error {
+	_, span := t.start(context.Background(), "PruneVersions", bucket, "", -1)
+	err := t.helper.PruneVersions(bucket, prefix, keep)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) ListDeleteMarkers(bucket, prefix string) ([]minio.ObjectInfo, error) {
+	_, span := t.start(context.Background(), "ListDeleteMarkers", bucket, "", -1)
+	objects, err := t.helper.ListDeleteMarkers(bucket, prefix)
+	endSpan(span, err)
+	return objects, err
+}
+
+func (t tracedHelper) PurgeDeleteMarkers(bucket, prefix string) (int, error) {
+	_, span := t.start(context.Background(), "PurgeDeleteMarkers", bucket, "", -1)
+	n, err := t.helper.PurgeDeleteMarkers(bucket, prefix)
+	endSpan(span, err)
+	return n, err
+}
+
+func (t tracedHelper) SelectCSV(bucket, directory, filename, sqlExpression string) (io.ReadCloser, error) {
+	_, span := t.start(context.Background(), "SelectCSV", bucket, filepath.Join(directory, filename), -1)
+	reader, err := t.helper.SelectCSV(bucket, directory, filename, sqlExpression)
+	endSpan(span, err)
+	return reader, err
+}
+
+func (t tracedHelper) GetFileResilient(bucket, directory, filename string) (io.ReadCloser, bool, error) {
+	_, span := t.start(context.Background(), "GetFileResilient", bucket, filepath.Join(directory, filename), -1)
+	reader, ok, err := t.helper.GetFileResilient(bucket, directory, filename)
+	endSpan(span, err)
+	return reader, ok, err
+}
+
+func (t tracedHelper) GetFileDecoded(bucket, directory, filename string) (io.ReadCloser, bool, error) {
+	_, span := t.start(context.Background(), "GetFileDecoded", bucket, filepath.Join(directory, filename), -1)
+	reader, ok, err := t.helper.GetFileDecoded(bucket, directory, filename)
+	endSpan(span, err)
+	return reader, ok, err
+}
+
+func (t tracedHelper) GetFileSniffed(bucket, directory, filename string) (io.ReadCloser, string, bool, error) {
+	_, span := t.start(context.Background(), "GetFileSniffed", bucket, filepath.Join(directory, filename), -1)
+	reader, mime, ok, err := t.helper.GetFileSniffed(bucket, directory, filename)
+	endSpan(span, err)
+	return reader, mime, ok, err
+}
+
+func (t tracedHelper) GetFileLines(bucket, directory, filename string) (*bufio.Scanner, func() error, error) {
+	_, span := t.start(context.Background(), "GetFileLines", bucket, filepath.Join(directory, filename), -1)
+	scanner, closeFn, err := t.helper.GetFileLines(bucket, directory, filename)
+	endSpan(span, err)
+	return scanner, closeFn, err
+}
+
+func (t tracedHelper) CreateFileCompressed(bucket, directory, fileName string, content io.Reader, mime string) error {
+	_, span := t.start(context.Background(), "CreateFileCompressed", bucket, t.helper.uploadKey(directory, fileName), -1)
+	err := t.helper.CreateFileCompressed(bucket, directory, fileName, content, mime)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateImageFile(bucket, directory, fileName string, content io.Reader, mime string) error {
+	_, span := t.start(context.Background(), "CreateImageFile", bucket, t.helper.uploadKey(directory, fileName), -1)
+	err := t.helper.CreateImageFile(bucket, directory, fileName, content, mime)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileDedup(bucket, directory string, content io.Reader, mime string) (string, bool, error) {
+	_, span := t.start(context.Background(), "CreateFileDedup", bucket, "", -1)
+	key, ok, err := t.helper.CreateFileDedup(bucket, directory, content, mime)
+	if err == nil {
+		span.SetAttribute(AttributeKey, key)
+	}
+	endSpan(span, err)
+	return key, ok, err
+}
+
+func (t tracedHelper) BrowseDirectory(bucket, prefix string, sortBy string, ascending bool, offset, limit int) ([]string, []minio.ObjectInfo, int, error) {
+	_, span := t.start(context.Background(), "BrowseDirectory", bucket, "", -1)
+	prefixes, objects, total, err := t.helper.BrowseDirectory(bucket, prefix, sortBy, ascending, offset, limit)
+	endSpan(span, err)
+	return prefixes, objects, total, err
+}
+
+func (t tracedHelper) GenerateManifest(bucket, prefix string) ([]byte, error) {
+	_, span := t.start(context.Background(), "GenerateManifest", bucket, "", -1)
+	manifest, err := t.helper.GenerateManifest(bucket, prefix)
+	endSpan(span, err)
+	return manifest, err
+}
+
+func (t tracedHelper) WriteManifest(bucket, prefix string) error {
+	_, span := t.start(context.Background(), "WriteManifest", bucket, "", -1)
+	err := t.helper.WriteManifest(bucket, prefix)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) ListFilesAfter(bucket, prefix, startAfter string, recursive bool) ([]minio.ObjectInfo, error) {
+	_, span := t.start(context.Background(), "ListFilesAfter", bucket, "", -1)
+	objects, err := t.helper.ListFilesAfter(bucket, prefix, startAfter, recursive)
+	endSpan(span, err)
+	return objects, err
+}
+
+func (t tracedHelper) GlobFiles(bucket, pattern string) ([]string, error) {
+	_, span := t.start(context.Background(), "GlobFiles", bucket, "", -1)
+	keys, err := t.helper.GlobFiles(bucket, pattern)
+	endSpan(span, err)
+	return keys, err
+}
+
+func (t tracedHelper) ListFilesModifiedSince(bucket, prefix string, since time.Time) ([]minio.ObjectInfo, error) {
+	_, span := t.start(context.Background(), "ListFilesModifiedSince", bucket, "", -1)
+	objects, err := t.helper.ListFilesModifiedSince(bucket, prefix, since)
+	endSpan(span, err)
+	return objects, err
+}
+
+func (t tracedHelper) CopyFileWithTags(src, dst SourceRef, tags map[string]string, replaceTags bool) error {
+	_, span := t.start(context.Background(), "CopyFileWithTags", src.Bucket, filepath.Join(src.Directory, src.FileName), -1)
+	err := t.helper.CopyFileWithTags(src, dst, tags, replaceTags)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CloneFile(src, dst SourceRef) error {
+	_, span := t.start(context.Background(), "CloneFile", src.Bucket, filepath.Join(src.Directory, src.FileName), -1)
+	err := t.helper.CloneFile(src, dst)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) RenameFile(bucket, directory, oldName, newName string) error {
+	_, span := t.start(context.Background(), "RenameFile", bucket, filepath.Join(directory, oldName), -1)
+	err := t.helper.RenameFile(bucket, directory, oldName, newName)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) ChangeStorageClass(bucket, directory, filename, storageClass string) error {
+	_, span := t.start(context.Background(), "ChangeStorageClass", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.ChangeStorageClass(bucket, directory, filename, storageClass)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) TouchFile(bucket, directory, filename string) error {
+	_, span := t.start(context.Background(), "TouchFile", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.TouchFile(bucket, directory, filename)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) SetRedirect(bucket, directory, filename, target string) error {
+	_, span := t.start(context.Background(), "SetRedirect", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.SetRedirect(bucket, directory, filename, target)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) MakePrefixPublicRead(bucket, prefix string) error {
+	_, span := t.start(context.Background(), "MakePrefixPublicRead", bucket, "", -1)
+	err := t.helper.MakePrefixPublicRead(bucket, prefix)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) UpdateBucketPolicy(bucket string, edit func(policy *BucketPolicyDoc) error) error {
+	_, span := t.start(context.Background(), "UpdateBucketPolicy", bucket, "", -1)
+	err := t.helper.UpdateBucketPolicy(bucket, edit)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) NewObjectReaderAt(bucket, directory, filename string) (io.ReaderAt, int64, error) {
+	_, span := t.start(context.Background(), "NewObjectReaderAt", bucket, filepath.Join(directory, filename), -1)
+	ra, size, err := t.helper.NewObjectReaderAt(bucket, directory, filename)
+	endSpan(span, err)
+	return ra, size, err
+}
+
+func (t tracedHelper) TrashFile(bucket, directory, filename string) error {
+	_, span := t.start(context.Background(), "TrashFile", bucket, filepath.Join(directory, filename), -1)
+	err := t.helper.TrashFile(bucket, directory, filename)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) RestoreFromTrash(bucket, originalDir, filename string) error {
+	_, span := t.start(context.Background(), "RestoreFromTrash", bucket, filepath.Join(originalDir, filename), -1)
+	err := t.helper.RestoreFromTrash(bucket, originalDir, filename)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) RestrictPresignedSourceIP(bucket, prefix string, sourceIPs ...string) error {
+	_, span := t.start(context.Background(), "RestrictPresignedSourceIP", bucket, "", -1)
+	err := t.helper.RestrictPresignedSourceIP(bucket, prefix, sourceIPs...)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) PresignedGetURLFromIP(bucket, directory, filename string, expiry time.Duration, sourceIP string) (*url.URL, error) {
+	_, span := t.start(context.Background(), "PresignedGetURLFromIP", bucket, filepath.Join(directory, filename), -1)
+	u, err := t.helper.PresignedGetURLFromIP(bucket, directory, filename, expiry, sourceIP)
+	endSpan(span, err)
+	return u, err
+}
+
+func (t tracedHelper) CreateDirectoryDefault(name string) error {
+	_, span := t.start(context.Background(), "CreateDirectoryDefault", t.helper.DefaultBucket(), "", -1)
+	err := t.helper.CreateDirectoryDefault(name)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) CreateFileDefault(directory, fileName string, content io.Reader, length int64, mime string, opts ...UploadOption) error {
+	_, span := t.start(context.Background(), "CreateFileDefault", t.helper.DefaultBucket(), t.helper.uploadKey(directory, fileName), length)
+	err := t.helper.CreateFileDefault(directory, fileName, content, length, mime, opts...)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) GetFileDefault(directory, filename string) (*minio.Object, error) {
+	_, span := t.start(context.Background(), "GetFileDefault", t.helper.DefaultBucket(), filepath.Join(directory, filename), -1)
+	obj, err := t.helper.GetFileDefault(directory, filename)
+	endSpan(span, err)
+	return obj, err
+}
+
+func (t tracedHelper) FileExistsDefault(directory, filename string) (bool, error) {
+	_, span := t.start(context.Background(), "FileExistsDefault", t.helper.DefaultBucket(), filepath.Join(directory, filename), -1)
+	ok, err := t.helper.FileExistsDefault(directory, filename)
+	endSpan(span, err)
+	return ok, err
+}
+
+func (t tracedHelper) RemoveDirectoryDefault(directory string) error {
+	_, span := t.start(context.Background(), "RemoveDirectoryDefault", t.helper.DefaultBucket(), "", -1)
+	err := t.helper.RemoveDirectoryDefault(directory)
+	endSpan(span, err)
+	return err
+}
+
+func (t tracedHelper) RemoveFileDefault(directory, fileName string) error {
+	_, span := t.start(context.Background(), "RemoveFileDefault", t.helper.DefaultBucket(), filepath.Join(directory, fileName), -1)
+	err := t.helper.RemoveFileDefault(directory, fileName)
+	endSpan(span, err)
+	return err
+}
+
+// The local operations are passed to the wrapped helper without a span.
+
+func (t tracedHelper) GetS3Host() string {
+	return t.helper.GetS3Host()
+}
+
+func (t tracedHelper) GetBucketName() string {
+	return t.helper.GetBucketName()
+}
+
+func (t tracedHelper) DefaultBucket() string {
+	return t.helper.DefaultBucket()
+}
+
+func (t tracedHelper) ResolveBucket(alias string) (string, error) {
+	return t.helper.ResolveBucket(alias)
+}
+
+func (t tracedHelper) ResolveKey(directory, fileName string, uploaded time.Time) string {
+	return t.helper.ResolveKey(directory, fileName, uploaded)
+}
+
+func (t tracedHelper) PresignedGetURLs(bucket string, keys []KeyRef, expiry time.Duration) (map[string]*url.URL, error) {
+	return t.helper.PresignedGetURLs(bucket, keys, expiry)
+}
+
+func (t tracedHelper) UploadGrant(bucket, keyPrefix string, maxSize int64, allowedMimePrefix string, expiry time.Duration) (*UploadGrant, error) {
+	return t.helper.UploadGrant(bucket, keyPrefix, maxSize, allowedMimePrefix, expiry)
+}
+
+func (t tracedHelper) PresignedDownloadURL(bucket, directory, filename, downloadName string, expiry time.Duration) (*url.URL, error) {
+	return t.helper.PresignedDownloadURL(bucket, directory, filename, downloadName, expiry)
+}
+
+func (t tracedHelper) PresignedHeadURL(bucket, directory, filename string, expiry time.Duration) (*url.URL, error) {
+	return t.helper.PresignedHeadURL(bucket, directory, filename, expiry)
+}
+
+func (t tracedHelper) PresignedGetURLWithHeaders(bucket, directory, filename string, expiry time.Duration, respHeaders map[string]string) (*url.URL, error) {
+	return t.helper.PresignedGetURLWithHeaders(bucket, directory, filename, expiry, respHeaders)
+}
+
+func (t tracedHelper) PresignedGetURLString(bucket, directory, filename string, expiry time.Duration) (string, error) {
+	return t.helper.PresignedGetURLString(bucket, directory, filename, expiry)
+}
+
+func (t tracedHelper) PublicURL(bucket, directory, filename string) (*url.URL, error) {
+	return t.helper.PublicURL(bucket, directory, filename)
+}
+
+func (t tracedHelper) PublicURLString(bucket, directory, filename string) (string, error) {
+	return t.helper.PublicURLString(bucket, directory, filename)
+}
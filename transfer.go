@@ -0,0 +1,322 @@
+package s3
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// UploadResult is the result of UploadDirectory.
+type UploadResult struct {
+	Uploaded int
+	Skipped  int
+}
+
+// DownloadResult is the result of DownloadDirectory.
+type DownloadResult struct {
+	Downloaded int
+	Skipped    int
+}
+
+// detectContentType returns the content type of the file based on its
+// extension. If the extension is missing or unknown, the type is detected from
+// the first 512 bytes of the content, and the content is rewound.
+func detectContentType(name string, content io.ReadSeeker) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType, nil
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", errors.Wrap(err, "read failed")
+	}
+
+	_, err = content.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", errors.Wrap(err, "seek failed")
+	}
+
+	return http.DetectContentType(head[:n]), nil
+}
+
+// UploadDirectory uploads the files of the local directory recursively under
+// the prefix, with the content type based on their extension, or on their
+// content if the extension is unknown. The symlinks are skipped. The files
+// which failed to upload are returned as BatchError, keyed by their local path.
+func (s helper) UploadDirectory(bucket, localDir, destPrefix string, concurrency int) (UploadResult, error) {
+	result := UploadResult{}
+	if !s.Enabled {
+		return result, ErrServerDisabled
+	}
+
+	err := validation.Validate(concurrency, validation.Required, validation.Min(1))
+	if err != nil {
+		return result, errors.Wrap(err, "invalid concurrency")
+	}
+
+	var mu sync.Mutex
+	failed := BatchError{}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	err = filepath.Walk(localDir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			mu.Lock()
+			result.Skipped++
+			mu.Unlock()
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(localDir, name)
+		if err != nil {
+			return err
+		}
+		key := path.Join(destPrefix, filepath.ToSlash(rel))
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := s.uploadLocalFile(bucket, key, name, info.Size())
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[name] = err
+				return
+			}
+			result.Uploaded++
+		}()
+		return nil
+	})
+	wg.Wait()
+
+	if err != nil {
+		return result, errors.Wrap(err, "walk failed")
+	}
+	if len(failed) > 0 {
+		return result, failed
+	}
+	return result, nil
+}
+
+// uploadLocalFile uploads the local file to the key.
+func (s helper) uploadLocalFile(bucket, key, name string, size int64) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	contentType, err := detectContentType(name, file)
+	if err != nil {
+		return err
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType: contentType,
+	}
+	return s.putObject(bucket, key, file, size, opts)
+}
+
+// DownloadDirectory downloads the objects under the prefix recursively into
+// the local directory, keeping their paths relative to the prefix. The
+// directory markers are skipped. The objects which failed to download are
+// returned as BatchError, keyed by their key.
+func (s helper) DownloadDirectory(bucket, prefix, localDir string, concurrency int) (DownloadResult, error) {
+	result := DownloadResult{}
+	if !s.Enabled {
+		return result, ErrServerDisabled
+	}
+
+	err := validation.Validate(concurrency, validation.Required, validation.Min(1))
+	if err != nil {
+		return result, errors.Wrap(err, "invalid concurrency")
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	var mu sync.Mutex
+	failed := BatchError{}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for obj := range s.Client.ListObjectsV2(bucket, prefix, true, doneCh) {
+		if obj.Err != nil {
+			err = errors.Wrap(obj.Err, "list object error")
+			break
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(obj.Key, prefix), "/")
+		if rel == "" || strings.HasSuffix(obj.Key, "/") || path.Base(obj.Key) == directoryMarker {
+			mu.Lock()
+			result.Skipped++
+			mu.Unlock()
+			continue
+		}
+
+		name := filepath.Join(localDir, filepath.FromSlash(rel))
+		if !strings.HasPrefix(name, filepath.Clean(localDir)+string(filepath.Separator)) {
+			mu.Lock()
+			failed[obj.Key] = errors.New("key is outside of the directory")
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := s.Client.FGetObject(bucket, key, name, minio.GetObjectOptions{})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[key] = err
+				return
+			}
+			result.Downloaded++
+		}(obj.Key)
+	}
+	wg.Wait()
+
+	if err != nil {
+		return result, err
+	}
+	if len(failed) > 0 {
+		return result, failed
+	}
+	return result, nil
+}
+
+// offsetWriter writes to the file sequentially from the offset.
+type offsetWriter struct {
+	file   *os.File
+	offset int64
+}
+
+// Write implements io.Writer.
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.file.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
+
+// DownloadToFileParallel downloads the file into the local path with the given
+// number of concurrent ranged requests. Every part is written at its offset
+// of the local file. The local file is removed if the download fails.
+func (s helper) DownloadToFileParallel(bucket, directory, filename, localPath string, parts int) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validation.Validate(parts, validation.Required, validation.Min(1))
+	if err != nil {
+		return errors.Wrap(err, "invalid parts")
+	}
+
+	info, found, err := s.statFile(bucket, directory, filename)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("file not found")
+	}
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return errors.Wrap(err, "create failed")
+	}
+
+	err = s.downloadParts(file, bucket, filepath.Join(directory, filename), info, parts)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = errors.Wrap(closeErr, "close failed")
+	}
+	if err != nil {
+		os.Remove(localPath)
+		return err
+	}
+
+	return nil
+}
+
+// downloadParts downloads the object into the file in concurrent parts.
+func (s helper) downloadParts(file *os.File, bucket, key string, info minio.ObjectInfo, parts int) error {
+	err := file.Truncate(info.Size)
+	if err != nil {
+		return errors.Wrap(err, "truncate failed")
+	}
+	if info.Size == 0 {
+		return nil
+	}
+
+	if int64(parts) > info.Size {
+		parts = int(info.Size)
+	}
+	partSize := (info.Size + int64(parts) - 1) / int64(parts)
+
+	core := minio.Core{Client: s.Client}
+	errs := make(chan error, parts)
+	for start := int64(0); start < info.Size; start += partSize {
+		end := start + partSize - 1
+		if end >= info.Size {
+			end = info.Size - 1
+		}
+
+		go func(start, end int64) {
+			opts := minio.GetObjectOptions{}
+			err := opts.SetRange(start, end)
+			if err != nil {
+				errs <- err
+				return
+			}
+			// The parts must not mix the content of different uploads.
+			err = opts.SetMatchETag(info.ETag)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			body, _, err := core.GetObject(bucket, key, opts)
+			if err != nil {
+				errs <- errors.Wrap(err, "GetObject failed")
+				return
+			}
+			defer body.Close()
+
+			n, err := io.Copy(&offsetWriter{file: file, offset: start}, body)
+			if err == nil && n != end-start+1 {
+				err = io.ErrUnexpectedEOF
+			}
+			errs <- errors.Wrap(err, "download part failed")
+		}(start, end)
+	}
+
+	for start := int64(0); start < info.Size; start += partSize {
+		if partErr := <-errs; partErr != nil && err == nil {
+			err = partErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,227 @@
+package s3
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// writeTree creates the files with their content under the directory.
+func writeTree(dir string, files map[string]string) {
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		So(os.MkdirAll(filepath.Dir(name), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(name, []byte(content), 0644), ShouldBeNil)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	Convey("UploadDirectory", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.UploadDirectory("x43563", ".", "site", 1)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid concurrency", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			_, err := s3.UploadDirectory("x43563", ".", "site", 0)
+			So(err, ShouldNotBeNil)
+		})
+
+		dir, err := ioutil.TempDir("", "s3-upload")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Success", func() {
+			writeTree(dir, map[string]string{
+				"index.html":     "<html></html>",
+				"css/style.css":  "body {}",
+				"js/app/main.js": "main()",
+			})
+			So(os.Symlink(filepath.Join(dir, "index.html"), filepath.Join(dir, "link.html")), ShouldBeNil)
+
+			result, err := s3.UploadDirectory("x43563", dir, "site", 2)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, UploadResult{Uploaded: 3, Skipped: 1})
+			So(server.keys("x43563"), ShouldResemble, []string{"site/css/style.css", "site/index.html", "site/js/app/main.js"})
+
+			obj, ok := server.get("x43563", "site/css/style.css")
+			So(ok, ShouldBeTrue)
+			So(string(obj.data), ShouldEqual, "body {}")
+			So(obj.header.Get("Content-Type"), ShouldStartWith, "text/css")
+		})
+
+		Convey("Content type without extension", func() {
+			writeTree(dir, map[string]string{
+				"images/logo": "\x89PNG\x0D\x0A\x1A\x0A" + strings.Repeat("\x00", 600),
+				"LICENSE":     "plain text",
+			})
+
+			result, err := s3.UploadDirectory("x43563", dir, "", 1)
+			So(err, ShouldBeNil)
+			So(result.Uploaded, ShouldEqual, 2)
+
+			obj, ok := server.get("x43563", "images/logo")
+			So(ok, ShouldBeTrue)
+			So(obj.header.Get("Content-Type"), ShouldEqual, "image/png")
+			So(obj.data, ShouldHaveLength, 608)
+
+			obj, ok = server.get("x43563", "LICENSE")
+			So(ok, ShouldBeTrue)
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain; charset=utf-8")
+			So(string(obj.data), ShouldEqual, "plain text")
+		})
+
+		Convey("Missing directory", func() {
+			_, err := s3.UploadDirectory("x43563", filepath.Join(dir, "missing"), "site", 2)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("DownloadDirectory", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.DownloadDirectory("x43563", "site", ".", 1)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid concurrency", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			_, err := s3.DownloadDirectory("x43563", "site", ".", 0)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			dir, err := ioutil.TempDir("", "s3-download")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			server := newFakeS3()
+			defer server.Close()
+			server.put("x43563", "site/index.html", []byte("<html></html>"), nil)
+			server.put("x43563", "site/css/style.css", []byte("body {}"), nil)
+			server.put("x43563", "site/css/.created", []byte("marker"), nil)
+			server.put("x43563", "site/js/app/main.js", []byte("main()"), nil)
+			server.put("x43563", "other/file.txt", []byte("other"), nil)
+			s3 := newTestHelper(server.Server)
+
+			result, err := s3.DownloadDirectory("x43563", "site/", dir, 2)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, DownloadResult{Downloaded: 3, Skipped: 1})
+
+			files := map[string]string{}
+			err = filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				data, err := ioutil.ReadFile(name)
+				rel, _ := filepath.Rel(dir, name)
+				files[filepath.ToSlash(rel)] = string(data)
+				return err
+			})
+			So(err, ShouldBeNil)
+			So(files, ShouldResemble, map[string]string{
+				"index.html":     "<html></html>",
+				"css/style.css":  "body {}",
+				"js/app/main.js": "main()",
+			})
+		})
+	})
+
+	Convey("DownloadToFileParallel", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.DownloadToFileParallel("x43563", "dir", "file.bin", "file.bin", 3)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid parts", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			err := s3.DownloadToFileParallel("x43563", "dir", "file.bin", "file.bin", 0)
+			So(err, ShouldNotBeNil)
+		})
+
+		dir, err := ioutil.TempDir("", "s3-parallel")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		content := make([]byte, 100000)
+		for i := range content {
+			content[i] = byte(i % 251)
+		}
+		server.put("x43563", "dir/file.bin", content, nil)
+
+		Convey("Success", func() {
+			name := filepath.Join(dir, "file.bin")
+			err := s3.DownloadToFileParallel("x43563", "dir", "file.bin", name, 3)
+			So(err, ShouldBeNil)
+
+			data, err := ioutil.ReadFile(name)
+			So(err, ShouldBeNil)
+			So(bytes.Equal(data, content), ShouldBeTrue)
+
+			ranges := []string{}
+			for _, r := range server.received() {
+				if r.Method == "GET" {
+					ranges = append(ranges, r.Header.Get("Range"))
+				}
+			}
+			sort.Strings(ranges)
+			So(ranges, ShouldResemble, []string{"bytes=0-33333", "bytes=33334-66667", "bytes=66668-99999"})
+		})
+
+		Convey("More parts than bytes", func() {
+			server.put("x43563", "dir/small.txt", []byte("ab"), nil)
+
+			name := filepath.Join(dir, "small.txt")
+			err := s3.DownloadToFileParallel("x43563", "dir", "small.txt", name, 8)
+			So(err, ShouldBeNil)
+
+			data, err := ioutil.ReadFile(name)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "ab")
+		})
+
+		Convey("Not found", func() {
+			name := filepath.Join(dir, "missing.bin")
+			err := s3.DownloadToFileParallel("x43563", "dir", "missing.bin", name, 3)
+			So(err, ShouldNotBeNil)
+
+			_, err = os.Stat(name)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+}
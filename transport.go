@@ -0,0 +1,113 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Default connection pool sizes, the same as the ones of net/http.
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = http.DefaultMaxIdleConnsPerHost
+)
+
+// newTransport creates the transport of the client from the config.
+func newTransport(config Config) *http.Transport {
+	maxIdleConns := config.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = defaultMaxIdleConns
+	}
+	maxIdleConnsPerHost := config.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost == 0 {
+		maxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+
+	return &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: newTLSConfig(config),
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// The objects stored with gzip content encoding must not be decoded.
+		DisableCompression: true,
+	}
+}
+
+// newTLSConfig creates the TLS config of the transport from the config. It is
+// nil, the net/http default, unless the TLS settings are configured.
+func newTLSConfig(config Config) *tls.Config {
+	if config.MinTLSVersion == 0 && len(config.PinnedCertSHA256) == 0 {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: config.MinTLSVersion,
+	}
+	if len(config.PinnedCertSHA256) > 0 {
+		pins := make([][]byte, len(config.PinnedCertSHA256))
+		for i, pin := range config.PinnedCertSHA256 {
+			pins[i], _ = parseCertPin(pin)
+		}
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyCertPin(rawCerts, pins)
+		}
+	}
+	return tlsConfig
+}
+
+// verifyCertPin checks that the SHA-256 fingerprint of the server certificate
+// matches one of the pins. It is called after the certificate chain is
+// verified.
+func verifyCertPin(rawCerts [][]byte, pins [][]byte) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no server certificate")
+	}
+
+	sum := sha256.Sum256(rawCerts[0])
+	for _, pin := range pins {
+		if bytes.Equal(sum[:], pin) {
+			return nil
+		}
+	}
+	return errors.Errorf("server certificate %s is not pinned", hex.EncodeToString(sum[:]))
+}
+
+// parseCertPin parses the hex SHA-256 fingerprint of a certificate. The bytes
+// can be separated by colons, as openssl prints them.
+func parseCertPin(pin string) ([]byte, error) {
+	sum, err := hex.DecodeString(strings.Replace(pin, ":", "", -1))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, errors.Errorf("invalid SHA-256 fingerprint: %s", pin)
+	}
+	return sum, nil
+}
+
+// validateCertPins checks that the pins are SHA-256 fingerprints.
+func validateCertPins(value interface{}) error {
+	for _, pin := range value.([]string) {
+		if _, err := parseCertPin(pin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// httpClient returns the client used for the requests not covered by minio.
+func (s helper) httpClient() *http.Client {
+	return &http.Client{Transport: s.transport}
+}
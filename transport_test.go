@@ -0,0 +1,172 @@
+package s3
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// baseTransport returns the http.Transport wrapped by the transports of the
+// helper.
+func baseTransport(s3 Helper) (*http.Transport, bool) {
+	transport := s3.(*helper).transport
+	for {
+		switch t := transport.(type) {
+		case *http.Transport:
+			return t, true
+		case *retryTransport:
+			transport = t.transport
+		case *skewTransport:
+			transport = t.transport
+		case *throttledTransport:
+			transport = t.transport
+		case *headerTransport:
+			transport = t.transport
+		case *ownerTransport:
+			transport = t.transport
+		default:
+			return nil, false
+		}
+	}
+}
+
+// trustServer makes the helper trust the certificate of the TLS test server.
+func trustServer(s3 Helper, server *httptest.Server) {
+	transport, ok := baseTransport(s3)
+	So(ok, ShouldBeTrue)
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.RootCAs = x509.NewCertPool()
+	transport.TLSClientConfig.RootCAs.AddCert(server.Certificate())
+}
+
+func TestTransport(t *testing.T) {
+	config := Config{
+		AccessKeyID:     "x",
+		Endpoint:        "localhost",
+		Region:          "x",
+		SecretAccessKey: "x",
+		BucketName:      "x",
+		SSL:             false,
+	}
+
+	Convey("Transport", t, func() {
+		Convey("Defaults", func() {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			transport, ok := baseTransport(s3)
+			So(ok, ShouldBeTrue)
+			So(transport.MaxIdleConns, ShouldEqual, 100)
+			So(transport.MaxIdleConnsPerHost, ShouldEqual, http.DefaultMaxIdleConnsPerHost)
+			So(transport.DisableCompression, ShouldBeTrue)
+		})
+
+		Convey("Configured", func() {
+			config.MaxIdleConns = 500
+			config.MaxIdleConnsPerHost = 50
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			transport, ok := baseTransport(s3)
+			So(ok, ShouldBeTrue)
+			So(transport.MaxIdleConns, ShouldEqual, 500)
+			So(transport.MaxIdleConnsPerHost, ShouldEqual, 50)
+		})
+
+		Convey("Invalid", func() {
+			config.MaxIdleConns = -1
+			s3, err := New(config)
+			So(err, ShouldNotBeNil)
+			So(s3, ShouldBeNil)
+		})
+	})
+
+	Convey("TLS", t, func() {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+		server.StartTLS()
+		defer server.Close()
+
+		sum := sha256.Sum256(server.Certificate().Raw)
+		pin := hex.EncodeToString(sum[:])
+
+		tlsConfig := Config{
+			AccessKeyID:     "x",
+			Endpoint:        strings.TrimPrefix(server.URL, "https://"),
+			Region:          "x",
+			SecretAccessKey: "x",
+			BucketName:      "x43563",
+			SSL:             true,
+		}
+
+		// newTLSHelper creates a helper which trusts the certificate of the
+		// test server.
+		newTLSHelper := func(config Config) Helper {
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+			transport, _ := baseTransport(s3)
+			So(transport.TLSClientConfig, ShouldNotBeNil)
+			trustServer(s3, server)
+			return s3
+		}
+
+		Convey("Matching pin", func() {
+			tlsConfig.PinnedCertSHA256 = []string{strings.Repeat("00", sha256.Size), pin}
+			s3 := newTLSHelper(tlsConfig)
+
+			exists, err := s3.BucketExists("x43563")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+		})
+
+		Convey("Colon separated pin", func() {
+			parts := []string{}
+			for i := 0; i < len(pin); i += 2 {
+				parts = append(parts, strings.ToUpper(pin[i:i+2]))
+			}
+			tlsConfig.PinnedCertSHA256 = []string{strings.Join(parts, ":")}
+			s3 := newTLSHelper(tlsConfig)
+
+			_, err := s3.BucketExists("x43563")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Non-matching pin", func() {
+			tlsConfig.PinnedCertSHA256 = []string{strings.Repeat("00", sha256.Size)}
+			s3 := newTLSHelper(tlsConfig)
+
+			_, err := s3.BucketExists("x43563")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "is not pinned")
+		})
+
+		Convey("Minimum version", func() {
+			tlsConfig.MinTLSVersion = tls.VersionTLS13
+			s3 := newTLSHelper(tlsConfig)
+
+			_, err := s3.BucketExists("x43563")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid settings", func() {
+			config := tlsConfig
+			config.PinnedCertSHA256 = []string{"abcd"}
+			_, err := New(config)
+			So(err, ShouldNotBeNil)
+
+			config = tlsConfig
+			config.MinTLSVersion = 1
+			_, err = New(config)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
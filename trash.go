@@ -0,0 +1,69 @@
+package s3
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultTrashPrefix is the prefix of the trashed files if the config does not
+// set one.
+const defaultTrashPrefix = ".trash/"
+
+// trashKey returns the key of the trashed file.
+func (s helper) trashKey(key string) string {
+	prefix := s.Config.TrashPrefix
+	if prefix == "" {
+		prefix = defaultTrashPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix + key
+}
+
+// moveObject copies the object to the new key and removes the original one. It
+// fails if the object does not exist.
+func (s helper) moveObject(bucket, src, dst string) error {
+	info, found, err := s.statObject(bucket, src)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("file not found")
+	}
+
+	err = s.copyObject(bucket, src, bucket, dst, info.Size, nil)
+	if err != nil {
+		return err
+	}
+	s.cache.set(fileCacheKey(bucket, dst), true)
+
+	err = s.removeObject(bucket, src)
+	if err != nil {
+		return errors.Wrap(err, "RemoveObject failed")
+	}
+
+	return nil
+}
+
+// TrashFile moves the file under the trash prefix, keeping its path.
+func (s helper) TrashFile(bucket, directory, filename string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	key := filepath.Join(directory, filename)
+	return s.moveObject(bucket, key, s.trashKey(key))
+}
+
+// RestoreFromTrash moves the trashed file back to its original directory.
+func (s helper) RestoreFromTrash(bucket, originalDir, filename string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	key := filepath.Join(originalDir, filename)
+	return s.moveObject(bucket, s.trashKey(key), key)
+}
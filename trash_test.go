@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTrash(t *testing.T) {
+	Convey("Trash", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.TrashFile("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+			err = s3.RestoreFromTrash("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), http.Header{"Content-Type": {"text/plain"}})
+
+		Convey("Trash and restore", func() {
+			s3 := newTestHelper(server.Server)
+
+			err := s3.TrashFile("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{".trash/dir/file.txt"})
+
+			obj, ok := server.get("x43563", ".trash/dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+
+			err = s3.RestoreFromTrash("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"dir/file.txt"})
+		})
+
+		Convey("Configured prefix", func() {
+			config := testConfig(server.Server)
+			config.TrashPrefix = "deleted"
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			err = s3.TrashFile("x43563", "dir", "file.txt")
+			So(err, ShouldBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"deleted/dir/file.txt"})
+		})
+
+		Convey("Missing file", func() {
+			s3 := newTestHelper(server.Server)
+
+			err := s3.RestoreFromTrash("x43563", "dir", "file.txt")
+			So(err, ShouldNotBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"dir/file.txt"})
+		})
+	})
+}
@@ -0,0 +1,278 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Storage classes which can be set on the uploaded objects.
+const (
+	StorageClassStandard           = "STANDARD"
+	StorageClassReducedRedundancy  = "REDUCED_REDUNDANCY"
+	StorageClassStandardIA         = "STANDARD_IA"
+	StorageClassOnezoneIA          = "ONEZONE_IA"
+	StorageClassIntelligentTiering = "INTELLIGENT_TIERING"
+	StorageClassGlacier            = "GLACIER"
+	StorageClassDeepArchive        = "DEEP_ARCHIVE"
+)
+
+// uploadOptions are the options of an upload.
+type uploadOptions struct {
+	minio.PutObjectOptions
+
+	// header holds the headers which are sent as they are, see WithHeader.
+	header http.Header
+}
+
+// context returns the context of the upload requests, carrying the headers
+// for headerTransport.
+func (o uploadOptions) context(ctx context.Context) context.Context {
+	if len(o.header) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, uploadHeaderKey{}, o.header)
+}
+
+// UploadOption modifies the options of an upload.
+type UploadOption func(opts *uploadOptions)
+
+// WithHeader sets a header on the upload request. The standard headers are
+// mapped to the matching upload options, the x-amz-acl, x-amz-grant-* and
+// x-amz-meta-* headers are sent by minio and any other header is added to
+// every request of the upload as it is, including the parts of a multipart
+// upload. Use WithMetadata for the user metadata.
+func WithHeader(key, value string) UploadOption {
+	return func(opts *uploadOptions) {
+		switch lower := strings.ToLower(key); {
+		case lower == "content-type":
+			opts.ContentType = value
+		case lower == "content-encoding":
+			opts.ContentEncoding = value
+		case lower == "content-disposition":
+			opts.ContentDisposition = value
+		case lower == "content-language":
+			opts.ContentLanguage = value
+		case lower == "cache-control":
+			opts.CacheControl = value
+		case lower == "x-amz-storage-class":
+			opts.StorageClass = value
+		case lower == "x-amz-website-redirect-location":
+			opts.WebsiteRedirectLocation = value
+		case lower == "x-amz-acl", strings.HasPrefix(lower, "x-amz-grant-"), strings.HasPrefix(lower, "x-amz-meta-"):
+			// The upload options have no field for these headers, but
+			// minio sends them without the metadata prefix.
+			if opts.UserMetadata == nil {
+				opts.UserMetadata = map[string]string{}
+			}
+			opts.UserMetadata[http.CanonicalHeaderKey(key)] = value
+		default:
+			if opts.header == nil {
+				opts.header = http.Header{}
+			}
+			opts.header.Set(key, value)
+		}
+	}
+}
+
+// WithMetadata stores the user metadata with the uploaded file, it is sent as
+// the x-amz-meta-<key> header.
+func WithMetadata(key, value string) UploadOption {
+	return func(opts *uploadOptions) {
+		if opts.UserMetadata == nil {
+			opts.UserMetadata = map[string]string{}
+		}
+		opts.UserMetadata[key] = value
+	}
+}
+
+// validateStorageClass checks that the storage class is a known one.
+func validateStorageClass(storageClass string) error {
+	return validation.Validate(storageClass, validation.Required, validation.In(
+		StorageClassStandard,
+		StorageClassReducedRedundancy,
+		StorageClassStandardIA,
+		StorageClassOnezoneIA,
+		StorageClassIntelligentTiering,
+		StorageClassGlacier,
+		StorageClassDeepArchive,
+	))
+}
+
+// CreateFileWithStorageClass make new file with the given storage class.
+func (s helper) CreateFileWithStorageClass(bucket, directory, fileName string, content io.Reader, length int64, mime, storageClass string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validateStorageClass(storageClass)
+	if err != nil {
+		return errors.Wrap(err, "invalid storage class")
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType:  mime,
+		StorageClass: storageClass,
+	}
+
+	return s.putObject(bucket, s.uploadKey(directory, fileName), content, length, opts)
+}
+
+// CreateFileWithCacheControl make new file with the given Cache-Control header.
+func (s helper) CreateFileWithCacheControl(bucket, directory, fileName string, content io.Reader, length int64, mime, cacheControl string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType:  mime,
+		CacheControl: cacheControl,
+	}
+
+	return s.putObject(bucket, s.uploadKey(directory, fileName), content, length, opts)
+}
+
+// CreateFileIfNotExists make new file unless it exists already. The created
+// flag is false if the file existed. The check and the upload are separate
+// requests, so a file created between them is overwritten. The key template
+// is not applied, the key is directory/fileName.
+func (s helper) CreateFileIfNotExists(bucket, directory, fileName string, content io.Reader, length int64, mime string) (bool, error) {
+	if !s.Enabled {
+		return false, ErrServerDisabled
+	}
+
+	key := filepath.Join(directory, fileName)
+	_, found, err := s.statObject(bucket, key)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		return false, nil
+	}
+
+	err = s.putObject(bucket, key, content, length, minio.PutObjectOptions{ContentType: mime})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetOrCreateFile returns the info of the file, and uploads it first unless it
+// exists already. The created flag is true if the file was uploaded. The check
+// and the upload are separate requests, so a file created between them is
+// overwritten. The key template is not applied, the key is directory/fileName.
+func (s helper) GetOrCreateFile(bucket, directory, fileName string, content io.Reader, length int64, mime string) (minio.ObjectInfo, bool, error) {
+	if !s.Enabled {
+		return minio.ObjectInfo{}, false, ErrServerDisabled
+	}
+
+	key := filepath.Join(directory, fileName)
+	info, found, err := s.statObject(bucket, key)
+	if err != nil {
+		return minio.ObjectInfo{}, false, err
+	}
+	if found {
+		return info, false, nil
+	}
+
+	err = s.putObject(bucket, key, content, length, minio.PutObjectOptions{ContentType: mime})
+	if err != nil {
+		return minio.ObjectInfo{}, false, err
+	}
+
+	info, found, err = s.statObject(bucket, key)
+	if err != nil {
+		return minio.ObjectInfo{}, false, err
+	}
+	if !found {
+		return minio.ObjectInfo{}, false, errors.New("created file does not exist")
+	}
+
+	return info, true, nil
+}
+
+// CreateFileWithExpires make new file with the given Expires header. The file
+// is uploaded in a single request, so the length must be known.
+func (s helper) CreateFileWithExpires(bucket, directory, fileName string, content io.Reader, length int64, mime string, expires time.Time) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validation.Validate(length, validation.Min(int64(0)))
+	if err != nil {
+		return errors.Wrap(err, "invalid length")
+	}
+
+	// The upload options do not support the Expires header, but the core
+	// client passes it through.
+	metadata := map[string]string{
+		"Content-Type": mime,
+		"Expires":      expires.UTC().Format(http.TimeFormat),
+	}
+
+	key := s.uploadKey(directory, fileName)
+	core := minio.Core{Client: s.Client}
+	_, err = core.PutObject(bucket, key, content, length, "", "", metadata, nil)
+	if err != nil {
+		return err
+	}
+
+	s.cache.set(fileCacheKey(bucket, key), true)
+	return nil
+}
+
+// CreateFileFromRequest make new file from the body of the request, using its
+// Content-Length and Content-Type. The body is streamed, a body of unknown
+// length is uploaded like by CreateFileStream, buffering up to a part.
+func (s helper) CreateFileFromRequest(bucket, directory, fileName string, r *http.Request) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	mime := r.Header.Get("Content-Type")
+	if mime == "" {
+		mime = "application/octet-stream"
+	}
+
+	length := r.ContentLength
+	if length < 0 {
+		length = -1
+	}
+
+	return s.CreateFile(bucket, directory, fileName, r.Body, length, mime)
+}
+
+// CreateFileSeekable make new file from the first size bytes of ra. The
+// content is read through a seekable reader, so a failed request is sent again
+// from the start without the caller reopening the source.
+func (s helper) CreateFileSeekable(bucket, directory, fileName string, ra io.ReaderAt, size int64, mime string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validation.Validate(size, validation.Min(int64(0)))
+	if err != nil {
+		return errors.Wrap(err, "invalid size")
+	}
+
+	return s.CreateFile(bucket, directory, fileName, io.NewSectionReader(ra, 0, size), size, mime)
+}
+
+// CreateFileStream make new file from a reader of unknown length, like a pipe.
+// The content is uploaded in parts as it is read, so the whole file is never
+// held in memory, but each part is buffered: up to UploadPartSize bytes, or
+// 576MiB if it is not configured, per upload in progress. The content which
+// ends within the first part is sent in a single request. An upload has at
+// most 10000 parts, which limits the file to 5TiB, or to 10000 times
+// UploadPartSize.
+func (s helper) CreateFileStream(bucket, directory, fileName string, content io.Reader, mime string) error {
+	return s.CreateFile(bucket, directory, fileName, content, -1, mime)
+}
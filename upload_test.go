@@ -0,0 +1,484 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	minio "github.com/minio/minio-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUpload(t *testing.T) {
+	Convey("CreateFileWithStorageClass", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithStorageClass("x43563", "dir", "file.txt", content, 4, "text/plain", StorageClassStandardIA)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid storage class", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithStorageClass("x43563", "dir", "file.txt", content, 4, "text/plain", "COLD")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var storageClass string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				storageClass = r.Header.Get("X-Amz-Storage-Class")
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithStorageClass("x43563", "dir", "file.txt", content, 4, "text/plain", StorageClassReducedRedundancy)
+			So(err, ShouldBeNil)
+			So(storageClass, ShouldEqual, StorageClassReducedRedundancy)
+		})
+	})
+
+	Convey("CreateFile with options", t, func() {
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+		}))
+		defer server.Close()
+
+		s3 := newTestHelper(server)
+		content := bytes.NewReader([]byte("asdf"))
+		err := s3.CreateFile("x43563", "dir", "file.txt", content, 4, "text/plain",
+			WithHeader("x-amz-acl", "public-read"),
+			WithHeader("X-Amz-Grant-Read", "id=123"),
+			WithHeader("Content-Disposition", "attachment"),
+			WithHeader("X-Gateway", "internal"),
+		)
+		So(err, ShouldBeNil)
+		So(header.Get("X-Amz-Acl"), ShouldEqual, "public-read")
+		So(header.Get("X-Amz-Grant-Read"), ShouldEqual, "id=123")
+		So(header.Get("X-Amz-Meta-X-Amz-Acl"), ShouldBeEmpty)
+		So(header.Get("X-Amz-Meta-X-Amz-Grant-Read"), ShouldBeEmpty)
+		So(header.Get("Content-Disposition"), ShouldEqual, "attachment")
+		So(header.Get("X-Gateway"), ShouldEqual, "internal")
+		So(header.Get("X-Amz-Meta-X-Gateway"), ShouldBeEmpty)
+		So(header.Get("Content-Type"), ShouldEqual, "text/plain")
+	})
+
+	Convey("CreateFile with headers", t, func() {
+		server := newFakeS3()
+		defer server.Close()
+
+		config := testConfig(server.Server)
+		config.UploadPartSize = minUploadPartSize
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Multipart upload", func() {
+			content := bytes.Repeat([]byte("x"), minUploadPartSize+1)
+			err := s3.CreateFile("x43563", "dir", "file.bin", bytes.NewReader(content), int64(len(content)), "application/octet-stream",
+				WithHeader("X-Gateway", "internal"),
+				WithMetadata("owner", "alice"),
+			)
+			So(err, ShouldBeNil)
+
+			requests := server.received()
+			So(requests, ShouldHaveLength, 4)
+			for _, r := range requests {
+				So(r.Header.Get("X-Gateway"), ShouldEqual, "internal")
+				So(r.Header.Get("Authorization"), ShouldContainSubstring, "x-gateway")
+			}
+			So(requests[0].Header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+		})
+
+		Convey("Chunk signed upload", func() {
+			err := s3.CreateFile("x43563", "dir", "file.txt", strings.NewReader("asdf"), 4, "text/plain",
+				WithHeader("X-Amz-Tagging", "a=b"),
+			)
+			So(err, ShouldNotBeNil)
+			So(server.keys("x43563"), ShouldBeEmpty)
+		})
+	})
+
+	Convey("PutObject", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.PutObject("x43563", "dir/file.txt", bytes.NewReader([]byte("asdf")), 4, minio.PutObjectOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var path string
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				header = r.Header
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.PutObject("x43563", "/dir//file.txt", bytes.NewReader([]byte("asdf")), 4, minio.PutObjectOptions{
+				ContentType:             "text/plain",
+				ContentLanguage:         "hu",
+				StorageClass:            StorageClassStandardIA,
+				WebsiteRedirectLocation: "/other.txt",
+				UserMetadata:            map[string]string{"owner": "alice"},
+			})
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, "/x43563/dir/file.txt")
+			So(header.Get("Content-Type"), ShouldEqual, "text/plain")
+			So(header.Get("Content-Language"), ShouldEqual, "hu")
+			So(header.Get("X-Amz-Storage-Class"), ShouldEqual, StorageClassStandardIA)
+			So(header.Get("X-Amz-Website-Redirect-Location"), ShouldEqual, "/other.txt")
+			So(header.Get("X-Amz-Meta-Owner"), ShouldEqual, "alice")
+		})
+
+		Convey("Empty key", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.PutObject("x43563", "/", bytes.NewReader([]byte("asdf")), 4, minio.PutObjectOptions{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CreateFileWithExpires", t, func() {
+		expires := time.Date(2030, 1, 2, 15, 4, 5, 0, time.FixedZone("CET", 3600))
+
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithExpires("x43563", "dir", "file.txt", content, 4, "text/plain", expires)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Unknown length", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithExpires("x43563", "dir", "file.txt", content, -1, "text/plain", expires)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := newFakeS3()
+			defer server.Close()
+
+			s3 := newTestHelper(server.Server)
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithExpires("x43563", "dir", "file.txt", content, 4, "text/plain", expires)
+			So(err, ShouldBeNil)
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.header.Get("Expires"), ShouldEqual, "Wed, 02 Jan 2030 14:04:05 GMT")
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+		})
+	})
+
+	Convey("CreateFileWithCacheControl", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithCacheControl("x43563", "dir", "file.txt", content, 4, "text/plain", "no-cache")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			content := bytes.NewReader([]byte("asdf"))
+			err := s3.CreateFileWithCacheControl("x43563", "dir", "file.txt", content, 4, "text/css", "public, max-age=31536000, immutable")
+			So(err, ShouldBeNil)
+			So(header.Get("Cache-Control"), ShouldEqual, "public, max-age=31536000, immutable")
+			So(header.Get("Content-Type"), ShouldEqual, "text/css")
+		})
+	})
+
+	Convey("CreateFileIfNotExists", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			content := bytes.NewReader([]byte("asdf"))
+			_, err := s3.CreateFileIfNotExists("x43563", "dir", "file.txt", content, 4, "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := newFakeS3()
+			defer server.Close()
+
+			s3 := newTestHelper(server.Server)
+			created, err := s3.CreateFileIfNotExists("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(created, ShouldBeTrue)
+
+			created, err = s3.CreateFileIfNotExists("x43563", "dir", "file.txt", bytes.NewReader([]byte("qwer")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(created, ShouldBeFalse)
+			So(server.count("PUT"), ShouldEqual, 1)
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+		})
+	})
+
+	Convey("GetOrCreateFile", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.GetOrCreateFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Created", func() {
+			info, created, err := s3.GetOrCreateFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(created, ShouldBeTrue)
+			So(info.Size, ShouldEqual, 4)
+			So(info.ContentType, ShouldEqual, "text/plain")
+			So(server.count("PUT"), ShouldEqual, 1)
+		})
+
+		Convey("Existing", func() {
+			server.put("x43563", "dir/file.txt", []byte("qwerty"), http.Header{"Content-Type": {"text/csv"}})
+
+			info, created, err := s3.GetOrCreateFile("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(created, ShouldBeFalse)
+			So(info.Size, ShouldEqual, 6)
+			So(info.ContentType, ShouldEqual, "text/csv")
+			So(server.count("PUT"), ShouldEqual, 0)
+		})
+	})
+
+	Convey("CreateFileSeekable", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CreateFileSeekable("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Retried", func() {
+			server.fail("PUT", "ServiceUnavailable")
+			content := bytes.Repeat([]byte("0123456789abcdef"), 1<<10)
+			// Only io.ReaderAt is exposed.
+			ra := struct{ io.ReaderAt }{bytes.NewReader(append(content, "trailing"...))}
+
+			err := s3.CreateFileSeekable("x43563", "dir", "file.bin", ra, int64(len(content)), "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(server.count("PUT"), ShouldEqual, 2)
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+		})
+
+		Convey("Invalid size", func() {
+			err := s3.CreateFileSeekable("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), -1, "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("CreateFileStream", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CreateFileStream("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		config := testConfig(server.Server)
+		config.UploadPartSize = 5 << 20
+		s3, err := New(config)
+		So(err, ShouldBeNil)
+
+		Convey("Pipe", func() {
+			content := bytes.Repeat([]byte("0123456789abcdef"), 6<<16)
+			reader, writer := io.Pipe()
+			go func() {
+				for i := 0; i < len(content); i += 1 << 16 {
+					writer.Write(content[i : i+1<<16])
+				}
+				writer.Close()
+			}()
+
+			err := s3.CreateFileStream("x43563", "dir", "file.bin", reader, "application/octet-stream")
+			So(err, ShouldBeNil)
+			So(server.count("POST"), ShouldEqual, 2)
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+			So(obj.etag, ShouldEndWith, "-2")
+			So(obj.header.Get("Content-Type"), ShouldEqual, "application/octet-stream")
+		})
+
+		Convey("Failed source", func() {
+			reader, writer := io.Pipe()
+			go func() {
+				writer.Write([]byte("asdf"))
+				writer.CloseWithError(io.ErrClosedPipe)
+			}()
+
+			err := s3.CreateFileStream("x43563", "dir", "file.bin", reader, "application/octet-stream")
+			So(err, ShouldNotBeNil)
+			So(server.keys("x43563"), ShouldBeEmpty)
+			So(server.uploadIDs(), ShouldBeEmpty)
+		})
+	})
+
+	Convey("CreateFileFromRequest", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			r := httptest.NewRequest("POST", "/upload", bytes.NewReader([]byte("asdf")))
+			err := s3.CreateFileFromRequest("x43563", "dir", "file.txt", r)
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Known length", func() {
+			r := httptest.NewRequest("POST", "/upload", bytes.NewReader([]byte("asdf")))
+			r.Header.Set("Content-Type", "text/plain")
+
+			err := s3.CreateFileFromRequest("x43563", "dir", "file.txt", r)
+			So(err, ShouldBeNil)
+			So(server.count("POST"), ShouldEqual, 0)
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+		})
+
+		Convey("Chunked", func() {
+			config := testConfig(server.Server)
+			config.UploadPartSize = minUploadPartSize
+			s3, err := New(config)
+			So(err, ShouldBeNil)
+
+			content := bytes.Repeat([]byte("0123456789abcdef"), minUploadPartSize/16+1)
+			r := httptest.NewRequest("POST", "/upload", ioutil.NopCloser(bytes.NewReader(content)))
+			r.ContentLength = -1
+			r.Header.Set("Transfer-Encoding", "chunked")
+
+			err = s3.CreateFileFromRequest("x43563", "dir", "file.bin", r)
+			So(err, ShouldBeNil)
+			So(server.count("POST"), ShouldEqual, 2)
+
+			obj, ok := server.get("x43563", "dir/file.bin")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, content)
+			So(obj.etag, ShouldEndWith, "-2")
+			So(obj.header.Get("Content-Type"), ShouldEqual, "application/octet-stream")
+		})
+	})
+
+	Convey("CreateFileAtomic", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.CreateFileAtomic("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Success", func() {
+			err := s3.CreateFileAtomic("x43563", "dir", "file.txt", bytes.NewReader([]byte("asdf")), 4, "text/plain")
+			So(err, ShouldBeNil)
+			So(server.keys("x43563"), ShouldResemble, []string{"dir/file.txt"})
+
+			obj, ok := server.get("x43563", "dir/file.txt")
+			So(ok, ShouldBeTrue)
+			So(obj.data, ShouldResemble, []byte("asdf"))
+			So(obj.header.Get("Content-Type"), ShouldEqual, "text/plain")
+
+			// The final key is only written by the copy of the complete upload.
+			requests := server.received()
+			So(requests, ShouldHaveLength, 4)
+			So(requests[0].Method, ShouldEqual, "PUT")
+			So(requests[0].Path, ShouldStartWith, "/x43563/dir/.tmp-")
+			So(requests[0].Path, ShouldEndWith, "-file.txt")
+			So(requests[1].Method, ShouldEqual, "HEAD")
+			So(requests[1].Path, ShouldEqual, requests[0].Path)
+			So(requests[2].Method, ShouldEqual, "PUT")
+			So(requests[2].Path, ShouldEqual, "/x43563/dir/file.txt")
+			So("/"+requests[2].Header.Get("X-Amz-Copy-Source"), ShouldEqual, requests[0].Path)
+			So(requests[3].Method, ShouldEqual, "DELETE")
+			So(requests[3].Path, ShouldEqual, requests[0].Path)
+		})
+
+		Convey("Failed upload", func() {
+			content := iotest.TimeoutReader(bytes.NewReader([]byte("asdf")))
+			err := s3.CreateFileAtomic("x43563", "dir", "file.txt", content, -1, "text/plain")
+			So(err, ShouldNotBeNil)
+			So(server.keys("x43563"), ShouldBeEmpty)
+		})
+	})
+}
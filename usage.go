@@ -0,0 +1,28 @@
+package s3
+
+import (
+	"github.com/pkg/errors"
+)
+
+// BucketStats returns the total size and the number of the objects in the
+// bucket. The objects are listed and counted page by page, the keys are not
+// kept in memory. Listing a large bucket takes one request per 1000 objects.
+func (s helper) BucketStats(bucket string) (int64, int64, error) {
+	if !s.Enabled {
+		return 0, 0, ErrServerDisabled
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	var totalBytes, objectCount int64
+	for obj := range s.Client.ListObjectsV2(bucket, "", true, doneCh) {
+		if obj.Err != nil {
+			return 0, 0, errors.Wrap(obj.Err, "list object error")
+		}
+		totalBytes += obj.Size
+		objectCount++
+	}
+
+	return totalBytes, objectCount, nil
+}
@@ -0,0 +1,44 @@
+package s3
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUsage(t *testing.T) {
+	Convey("BucketStats", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, _, err := s3.BucketStats("x43563")
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		s3 := newTestHelper(server.Server)
+
+		Convey("Empty", func() {
+			totalBytes, objectCount, err := s3.BucketStats("x43563")
+			So(err, ShouldBeNil)
+			So(totalBytes, ShouldEqual, 0)
+			So(objectCount, ShouldEqual, 0)
+		})
+
+		Convey("Seeded", func() {
+			server.put("x43563", "a.txt", []byte("asdf"), nil)
+			server.put("x43563", "dir/b.txt", []byte("0123456789"), nil)
+			server.put("x43563", "dir/sub/c.txt", []byte("x"), nil)
+			server.put("other", "d.txt", []byte("ignored"), http.Header{})
+
+			totalBytes, objectCount, err := s3.BucketStats("x43563")
+			So(err, ShouldBeNil)
+			So(totalBytes, ShouldEqual, 15)
+			So(objectCount, ShouldEqual, 3)
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package s3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// sha256Regexp matches a hex encoded SHA256 hash.
+var sha256Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// VerifyFile downloads the file and reports whether the SHA256 hash of its
+// content matches the expected hex encoded hash. The content is streamed
+// through the hasher, not held in memory.
+func (s helper) VerifyFile(bucket, directory, filename, expectedSHA256 string) (bool, error) {
+	if !s.Enabled {
+		return false, ErrServerDisabled
+	}
+
+	err := validation.Validate(expectedSHA256, validation.Required, validation.Match(sha256Regexp))
+	if err != nil {
+		return false, errors.Wrap(err, "invalid hash")
+	}
+
+	obj, _, found, err := s.openObject(bucket, filepath.Join(directory, filename), minio.GetObjectOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, errors.New("file does not exist")
+	}
+	defer obj.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, obj)
+	if err != nil {
+		return false, errors.Wrap(err, "read failed")
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)) == strings.ToLower(expectedSHA256), nil
+}
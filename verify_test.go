@@ -0,0 +1,56 @@
+package s3
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVerify(t *testing.T) {
+	// sha256 of "asdf"
+	const hash = "f0e4c2f76c58916ec258f246851bea091d14d4247a2fc3e18694461b1816e13b"
+
+	Convey("VerifyFile", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.VerifyFile("x43563", "dir", "file.txt", hash)
+			So(err, ShouldNotBeNil)
+		})
+
+		server := newFakeS3()
+		defer server.Close()
+		server.put("x43563", "dir/file.txt", []byte("asdf"), nil)
+		s3 := newTestHelper(server.Server)
+
+		Convey("Matching", func() {
+			ok, err := s3.VerifyFile("x43563", "dir", "file.txt", hash)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = s3.VerifyFile("x43563", "dir", "file.txt", strings.ToUpper(hash))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Not matching", func() {
+			ok, err := s3.VerifyFile("x43563", "dir", "file.txt", strings.Repeat("0", 64))
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Invalid hash", func() {
+			_, err := s3.VerifyFile("x43563", "dir", "file.txt", "asdf")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Not found", func() {
+			ok, err := s3.VerifyFile("x43563", "dir", "missing.txt", hash)
+			So(err, ShouldNotBeNil)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,256 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	minio "github.com/minio/minio-go"
+	"github.com/pkg/errors"
+)
+
+// Metadata headers which carry the version details of the listed versions.
+const (
+	HeaderVersionID    = "X-Amz-Version-Id"
+	HeaderDeleteMarker = "X-Amz-Delete-Marker"
+)
+
+// listVersionsResult represents the response of the list object versions request.
+type listVersionsResult struct {
+	IsTruncated         bool
+	NextKeyMarker       string
+	NextVersionIDMarker string `xml:"NextVersionIdMarker"`
+	// Versions keeps the Version and DeleteMarker elements in their order.
+	Versions []versionEntry `xml:",any"`
+}
+
+// versionEntry is a Version or DeleteMarker element of the listing.
+type versionEntry struct {
+	XMLName      xml.Name
+	Key          string
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool
+	LastModified time.Time
+	ETag         string
+	Size         int64
+	StorageClass string
+	Owner        struct {
+		DisplayName string
+		ID          string
+	}
+}
+
+// objectInfo converts the entry into an object info. The version id and the
+// delete marker flag are stored in the metadata.
+func (e versionEntry) objectInfo() minio.ObjectInfo {
+	deleteMarker := e.XMLName.Local == "DeleteMarker"
+
+	info := minio.ObjectInfo{
+		Key:          e.Key,
+		ETag:         trimETag(e.ETag),
+		LastModified: e.LastModified,
+		Size:         e.Size,
+		StorageClass: e.StorageClass,
+		Metadata: http.Header{
+			HeaderVersionID:    {e.VersionID},
+			HeaderDeleteMarker: {strconv.FormatBool(deleteMarker)},
+		},
+	}
+	info.Owner.DisplayName = e.Owner.DisplayName
+	info.Owner.ID = e.Owner.ID
+
+	return info
+}
+
+// trimETag removes the quotes around the ETag.
+func trimETag(etag string) string {
+	if len(etag) > 1 && etag[0] == '"' && etag[len(etag)-1] == '"' {
+		return etag[1 : len(etag)-1]
+	}
+	return etag
+}
+
+// VersionID returns the version id of a listed version.
+func VersionID(info minio.ObjectInfo) string {
+	return info.Metadata.Get(HeaderVersionID)
+}
+
+// IsDeleteMarker returns whether the listed version is a delete marker.
+func IsDeleteMarker(info minio.ObjectInfo) bool {
+	return info.Metadata.Get(HeaderDeleteMarker) == "true"
+}
+
+// ListFileVersions lists the versions and the delete markers of the objects
+// under the prefix, the versions of each key from the newest to the oldest.
+// Use VersionID and IsDeleteMarker to get the version details.
+func (s helper) ListFileVersions(bucket, prefix string) ([]minio.ObjectInfo, error) {
+	if !s.Enabled {
+		return nil, ErrServerDisabled
+	}
+
+	versions := []minio.ObjectInfo{}
+	keyMarker, versionIDMarker := "", ""
+	for {
+		query := url.Values{"versions": {""}, "prefix": {prefix}}
+		if keyMarker != "" {
+			query.Set("key-marker", keyMarker)
+		}
+		if versionIDMarker != "" {
+			query.Set("version-id-marker", versionIDMarker)
+		}
+
+		resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+			bucketName:  bucket,
+			queryValues: query,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "ListFileVersions failed")
+		}
+
+		result := listVersionsResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "xml.Decode failed")
+		}
+
+		for _, entry := range result.Versions {
+			if entry.XMLName.Local != "Version" && entry.XMLName.Local != "DeleteMarker" {
+				continue
+			}
+			versions = append(versions, entry.objectInfo())
+		}
+
+		if !result.IsTruncated {
+			return versions, nil
+		}
+		keyMarker, versionIDMarker = result.NextKeyMarker, result.NextVersionIDMarker
+	}
+}
+
+// removeObjectVersion permanently removes the given version of the object.
+func (s helper) removeObjectVersion(bucket, key, versionID string) error {
+	resp, err := s.executeMethod(context.Background(), "DELETE", requestMetadata{
+		bucketName:  bucket,
+		objectName:  key,
+		queryValues: url.Values{"versionId": {versionID}},
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// PruneVersions keeps the newest keep versions of each object under the prefix
+// and permanently removes the older ones. The delete markers are not counted
+// and are left in place.
+func (s helper) PruneVersions(bucket, prefix string, keep int) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validation.Validate(keep, validation.Required, validation.Min(1))
+	if err != nil {
+		return errors.Wrap(err, "invalid keep")
+	}
+
+	versions, err := s.ListFileVersions(bucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	kept := map[string]int{}
+	for _, version := range versions {
+		if IsDeleteMarker(version) {
+			continue
+		}
+
+		if kept[version.Key] < keep {
+			kept[version.Key]++
+			continue
+		}
+
+		err = s.removeObjectVersion(bucket, version.Key, VersionID(version))
+		if err != nil {
+			return errors.Wrap(err, "PruneVersions failed")
+		}
+	}
+
+	return nil
+}
+
+// DeleteObjectVersion permanently removes the given version of the file. A
+// version which does not exist counts as removed, so the call can be repeated.
+func (s helper) DeleteObjectVersion(bucket, directory, filename, versionID string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	err := validation.Validate(versionID, validation.Required)
+	if err != nil {
+		return errors.Wrap(err, "invalid version id")
+	}
+
+	key := filepath.Join(directory, filename)
+	err = s.removeObjectVersion(bucket, key, versionID)
+	if err, ok := err.(minio.ErrorResponse); ok && err.Code == "NoSuchVersion" {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "DeleteObjectVersion failed")
+	}
+
+	// The removed version may have been the current one.
+	s.cache.removePrefix(fileCacheKey(bucket, key))
+	return nil
+}
+
+// ListDeleteMarkers lists the delete markers of the objects under the prefix,
+// the markers of each key from the newest to the oldest.
+func (s helper) ListDeleteMarkers(bucket, prefix string) ([]minio.ObjectInfo, error) {
+	versions, err := s.ListFileVersions(bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	markers := []minio.ObjectInfo{}
+	for _, version := range versions {
+		if IsDeleteMarker(version) {
+			markers = append(markers, version)
+		}
+	}
+
+	return markers, nil
+}
+
+// PurgeDeleteMarkers permanently removes the delete markers of the objects
+// under the prefix and returns the number of the removed markers. Removing
+// the newest marker of an object makes its previous version current again.
+func (s helper) PurgeDeleteMarkers(bucket, prefix string) (int, error) {
+	markers, err := s.ListDeleteMarkers(bucket, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, marker := range markers {
+		err = s.removeObjectVersion(bucket, marker.Key, VersionID(marker))
+		if err, ok := err.(minio.ErrorResponse); ok && err.Code == "NoSuchVersion" {
+			continue
+		}
+		if err != nil {
+			return purged, errors.Wrap(err, "PurgeDeleteMarkers failed")
+		}
+		s.cache.removePrefix(fileCacheKey(bucket, marker.Key))
+		purged++
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,293 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const versionsPage1 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>x43563</Name>
+  <Prefix>dir/</Prefix>
+  <IsTruncated>true</IsTruncated>
+  <NextKeyMarker>dir/file.txt</NextKeyMarker>
+  <NextVersionIdMarker>v2</NextVersionIdMarker>
+  <DeleteMarker>
+    <Key>dir/file.txt</Key>
+    <VersionId>v3</VersionId>
+    <IsLatest>true</IsLatest>
+    <LastModified>2019-01-03T00:00:00.000Z</LastModified>
+  </DeleteMarker>
+  <Version>
+    <Key>dir/file.txt</Key>
+    <VersionId>v2</VersionId>
+    <IsLatest>false</IsLatest>
+    <LastModified>2019-01-02T00:00:00.000Z</LastModified>
+    <ETag>"etag2"</ETag>
+    <Size>5</Size>
+    <StorageClass>STANDARD</StorageClass>
+  </Version>
+</ListVersionsResult>`
+
+const versionsPage2 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>x43563</Name>
+  <Prefix>dir/</Prefix>
+  <IsTruncated>false</IsTruncated>
+  <Version>
+    <Key>dir/file.txt</Key>
+    <VersionId>v1</VersionId>
+    <IsLatest>false</IsLatest>
+    <LastModified>2019-01-01T00:00:00.000Z</LastModified>
+    <ETag>"etag1"</ETag>
+    <Size>4</Size>
+    <StorageClass>STANDARD</StorageClass>
+  </Version>
+</ListVersionsResult>`
+
+func TestVersions(t *testing.T) {
+	Convey("ListFileVersions", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.ListFileVersions("x43563", "dir/")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			queries := []map[string][]string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				queries = append(queries, r.URL.Query())
+				if r.URL.Query().Get("key-marker") == "" {
+					w.Write([]byte(versionsPage1))
+					return
+				}
+				w.Write([]byte(versionsPage2))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			versions, err := s3.ListFileVersions("x43563", "dir/")
+			So(err, ShouldBeNil)
+			So(versions, ShouldHaveLength, 3)
+
+			So(queries, ShouldHaveLength, 2)
+			So(queries[0], ShouldContainKey, "versions")
+			So(queries[0]["prefix"], ShouldResemble, []string{"dir/"})
+			So(queries[1]["key-marker"], ShouldResemble, []string{"dir/file.txt"})
+			So(queries[1]["version-id-marker"], ShouldResemble, []string{"v2"})
+
+			So(versions[0].Key, ShouldEqual, "dir/file.txt")
+			So(VersionID(versions[0]), ShouldEqual, "v3")
+			So(IsDeleteMarker(versions[0]), ShouldBeTrue)
+
+			So(VersionID(versions[1]), ShouldEqual, "v2")
+			So(IsDeleteMarker(versions[1]), ShouldBeFalse)
+			So(versions[1].ETag, ShouldEqual, "etag2")
+			So(versions[1].Size, ShouldEqual, 5)
+
+			So(VersionID(versions[2]), ShouldEqual, "v1")
+			So(IsDeleteMarker(versions[2]), ShouldBeFalse)
+			So(versions[2].LastModified.Day(), ShouldEqual, 1)
+		})
+
+		Convey("Error", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			_, err := s3.ListFileVersions("x43563", "dir/")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("PruneVersions", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.PruneVersions("x43563", "dir/", 1)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid keep", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			err := s3.PruneVersions("x43563", "dir/", 0)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			deleted := []string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "DELETE" {
+					deleted = append(deleted, r.URL.Path+"?"+r.URL.Query().Get("versionId"))
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				if r.URL.Query().Get("key-marker") == "" {
+					w.Write([]byte(versionsPage1))
+					return
+				}
+				w.Write([]byte(versionsPage2))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+
+			Convey("Keep one", func() {
+				err := s3.PruneVersions("x43563", "dir/", 1)
+				So(err, ShouldBeNil)
+				So(deleted, ShouldResemble, []string{"/x43563/dir/file.txt?v1"})
+			})
+
+			Convey("Keep all", func() {
+				err := s3.PruneVersions("x43563", "dir/", 2)
+				So(err, ShouldBeNil)
+				So(deleted, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("DeleteObjectVersion", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.DeleteObjectVersion("x43563", "dir", "file.txt", "v1")
+			So(err, ShouldNotBeNil)
+		})
+
+		deleted := []string{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deleted = append(deleted, r.Method+" "+r.URL.Path+"?"+r.URL.Query().Get("versionId"))
+			switch r.URL.Query().Get("versionId") {
+			case "v1":
+				w.WriteHeader(http.StatusNoContent)
+			case "missing":
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("<Error><Code>NoSuchVersion</Code></Error>"))
+			default:
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+			}
+		}))
+		defer server.Close()
+
+		s3 := newTestHelper(server)
+
+		Convey("Success", func() {
+			err := s3.DeleteObjectVersion("x43563", "dir", "file.txt", "v1")
+			So(err, ShouldBeNil)
+			So(deleted, ShouldResemble, []string{"DELETE /x43563/dir/file.txt?v1"})
+		})
+
+		Convey("Missing version", func() {
+			err := s3.DeleteObjectVersion("x43563", "dir", "file.txt", "missing")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Error", func() {
+			err := s3.DeleteObjectVersion("x43563", "dir", "file.txt", "v2")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Empty version", func() {
+			err := s3.DeleteObjectVersion("x43563", "dir", "file.txt", "")
+			So(err, ShouldNotBeNil)
+			So(deleted, ShouldBeEmpty)
+		})
+	})
+
+	Convey("ListDeleteMarkers", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.ListDeleteMarkers("x43563", "dir/")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("key-marker") == "" {
+					w.Write([]byte(versionsPage1))
+					return
+				}
+				w.Write([]byte(versionsPage2))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			markers, err := s3.ListDeleteMarkers("x43563", "dir/")
+			So(err, ShouldBeNil)
+			So(markers, ShouldHaveLength, 1)
+			So(markers[0].Key, ShouldEqual, "dir/file.txt")
+			So(VersionID(markers[0]), ShouldEqual, "v3")
+		})
+	})
+
+	Convey("PurgeDeleteMarkers", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.PurgeDeleteMarkers("x43563", "dir/")
+			So(err, ShouldNotBeNil)
+		})
+
+		deleted := []string{}
+		deleteStatus := http.StatusNoContent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "DELETE" {
+				deleted = append(deleted, r.URL.Path+"?"+r.URL.Query().Get("versionId"))
+				w.WriteHeader(deleteStatus)
+				if deleteStatus == http.StatusNotFound {
+					w.Write([]byte("<Error><Code>NoSuchVersion</Code></Error>"))
+				}
+				return
+			}
+			if r.URL.Query().Get("key-marker") == "" {
+				w.Write([]byte(versionsPage1))
+				return
+			}
+			w.Write([]byte(versionsPage2))
+		}))
+		defer server.Close()
+		s3 := newTestHelper(server)
+
+		Convey("Success", func() {
+			purged, err := s3.PurgeDeleteMarkers("x43563", "dir/")
+			So(err, ShouldBeNil)
+			So(purged, ShouldEqual, 1)
+			So(deleted, ShouldResemble, []string{"/x43563/dir/file.txt?v3"})
+		})
+
+		Convey("Already removed", func() {
+			deleteStatus = http.StatusNotFound
+			purged, err := s3.PurgeDeleteMarkers("x43563", "dir/")
+			So(err, ShouldBeNil)
+			So(purged, ShouldEqual, 0)
+		})
+
+		Convey("Error", func() {
+			deleteStatus = http.StatusForbidden
+			purged, err := s3.PurgeDeleteMarkers("x43563", "dir/")
+			So(err, ShouldNotBeNil)
+			So(purged, ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+)
+
+// WebsiteConfig represents the static website hosting configuration of a
+// bucket.
+type WebsiteConfig struct {
+	XMLName       xml.Name             `xml:"WebsiteConfiguration"`
+	IndexDocument WebsiteIndexDocument `xml:"IndexDocument"`
+	ErrorDocument WebsiteErrorDocument `xml:"ErrorDocument"`
+}
+
+// WebsiteIndexDocument is the document returned for the requests of the
+// directories, the suffix is appended to the path of the request.
+type WebsiteIndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+// WebsiteErrorDocument is the document returned when an error occurs.
+type WebsiteErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+// Validate validates the struct.
+func (c WebsiteConfig) Validate() error {
+	return validation.ValidateStruct(
+		&c,
+		validation.Field(&c.IndexDocument),
+		validation.Field(&c.ErrorDocument),
+	)
+}
+
+// Validate validates the struct.
+func (d WebsiteIndexDocument) Validate() error {
+	return validation.ValidateStruct(
+		&d,
+		validation.Field(&d.Suffix, validation.Required, validation.By(validateIndexSuffix)),
+	)
+}
+
+// Validate validates the struct.
+func (d WebsiteErrorDocument) Validate() error {
+	return validation.ValidateStruct(
+		&d,
+		validation.Field(&d.Key, validation.Required, validation.By(validateDocumentKey)),
+	)
+}
+
+// validateIndexSuffix checks that the index document is a file name, S3
+// appends it to the directories.
+func validateIndexSuffix(value interface{}) error {
+	if strings.Contains(value.(string), "/") {
+		return errors.New("must not contain a slash")
+	}
+	return nil
+}
+
+// validateDocumentKey checks that the error document is a key, not a path.
+func validateDocumentKey(value interface{}) error {
+	key := value.(string)
+	if strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return errors.New("must be an object key")
+	}
+	return nil
+}
+
+// SetBucketWebsite enables the static website hosting of the bucket with the
+// index and error documents.
+func (s helper) SetBucketWebsite(bucket, indexDocument, errorDocument string) error {
+	if !s.Enabled {
+		return ErrServerDisabled
+	}
+
+	config := WebsiteConfig{
+		IndexDocument: WebsiteIndexDocument{Suffix: indexDocument},
+		ErrorDocument: WebsiteErrorDocument{Key: errorDocument},
+	}
+	err := config.Validate()
+	if err != nil {
+		return errors.Wrap(err, "invalid website configuration")
+	}
+
+	content, err := xml.Marshal(config)
+	if err != nil {
+		return errors.Wrap(err, "xml.Marshal failed")
+	}
+
+	resp, err := s.executeMethod(context.Background(), "PUT", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"website": {""}},
+		content:     content,
+	})
+	if err != nil {
+		return errors.Wrap(err, "SetBucketWebsite failed")
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// GetBucketWebsite returns the static website hosting configuration of the
+// bucket.
+func (s helper) GetBucketWebsite(bucket string) (WebsiteConfig, error) {
+	config := WebsiteConfig{}
+	if !s.Enabled {
+		return config, ErrServerDisabled
+	}
+
+	resp, err := s.executeMethod(context.Background(), "GET", requestMetadata{
+		bucketName:  bucket,
+		queryValues: url.Values{"website": {""}},
+	})
+	if err != nil {
+		return config, errors.Wrap(err, "GetBucketWebsite failed")
+	}
+	defer resp.Body.Close()
+
+	err = xml.NewDecoder(resp.Body).Decode(&config)
+	if err != nil {
+		return config, errors.Wrap(err, "xml.Decode failed")
+	}
+
+	return config, nil
+}
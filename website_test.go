@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebsite(t *testing.T) {
+	Convey("SetBucketWebsite", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			err := s3.SetBucketWebsite("x43563", "index.html", "error.html")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Invalid documents", func() {
+			s3 := helper{
+				Enabled: true,
+			}
+
+			So(s3.SetBucketWebsite("x43563", "", "error.html"), ShouldNotBeNil)
+			So(s3.SetBucketWebsite("x43563", "index.html", ""), ShouldNotBeNil)
+			So(s3.SetBucketWebsite("x43563", "pages/index.html", "error.html"), ShouldNotBeNil)
+			So(s3.SetBucketWebsite("x43563", "index.html", "/error.html"), ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			var method, body string
+			var query map[string][]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method = r.Method
+				query = r.URL.Query()
+				b, _ := ioutil.ReadAll(r.Body)
+				body = string(b)
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			err := s3.SetBucketWebsite("x43563", "index.html", "errors/404.html")
+			So(err, ShouldBeNil)
+			So(method, ShouldEqual, "PUT")
+			So(query, ShouldContainKey, "website")
+			So(body, ShouldContainSubstring, "<IndexDocument><Suffix>index.html</Suffix></IndexDocument>")
+			So(body, ShouldContainSubstring, "<ErrorDocument><Key>errors/404.html</Key></ErrorDocument>")
+		})
+	})
+
+	Convey("GetBucketWebsite", t, func() {
+		Convey("Disabled S3", func() {
+			s3 := helper{
+				Enabled: false,
+			}
+
+			_, err := s3.GetBucketWebsite("x43563")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Success", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+					`<IndexDocument><Suffix>index.html</Suffix></IndexDocument>` +
+					`<ErrorDocument><Key>errors/404.html</Key></ErrorDocument>` +
+					`</WebsiteConfiguration>`))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			config, err := s3.GetBucketWebsite("x43563")
+			So(err, ShouldBeNil)
+			So(config.IndexDocument.Suffix, ShouldEqual, "index.html")
+			So(config.ErrorDocument.Key, ShouldEqual, "errors/404.html")
+		})
+
+		Convey("Not configured", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("<Error><Code>NoSuchWebsiteConfiguration</Code></Error>"))
+			}))
+			defer server.Close()
+
+			s3 := newTestHelper(server)
+			_, err := s3.GetBucketWebsite("x43563")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}